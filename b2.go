@@ -0,0 +1,36 @@
+package restic
+
+import (
+	"context"
+	"strings"
+)
+
+// B2Credentials holds the application key of a Backblaze B2 account
+type B2Credentials struct {
+	AccountID  string
+	AccountKey string
+}
+
+// env returns the environment variables restic reads the credentials from
+func (c B2Credentials) env() map[string]string {
+	return map[string]string{
+		"B2_ACCOUNT_ID":  c.AccountID,
+		"B2_ACCOUNT_KEY": c.AccountKey,
+	}
+}
+
+// B2Repo returns the repository string of the bucket and prefix
+func B2Repo(bucket string, prefix string) string {
+	repo := "b2:" + bucket
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		repo += ":" + prefix
+	}
+
+	return repo
+}
+
+// OpenB2 connects to an existing repository stored in a Backblaze B2 bucket under the prefix.
+func OpenB2(ctx context.Context, bucket string, prefix string, creds B2Credentials, password string, opts ...Option) (*Repository, error) {
+	opts = append([]Option{WithEnv(creds.env())}, opts...)
+	return Connect(ctx, B2Repo(bucket, prefix), password, opts...)
+}
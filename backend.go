@@ -0,0 +1,152 @@
+package restic
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BackendType is the storage backend of a repository
+type BackendType int
+
+const (
+	BackendLocal BackendType = iota
+	BackendS3
+	BackendSFTP
+	BackendREST
+	BackendB2
+	BackendAzure
+	BackendGCS
+	BackendSwift
+	BackendRclone
+)
+
+// backendPrefixes maps the repository string prefixes to the backends
+var backendPrefixes = []struct {
+	prefix  string
+	backend BackendType
+}{
+	{"local:", BackendLocal},
+	{"s3:", BackendS3},
+	{"sftp:", BackendSFTP},
+	{"rest:", BackendREST},
+	{"b2:", BackendB2},
+	{"azure:", BackendAzure},
+	{"gs:", BackendGCS},
+	{"swift:", BackendSwift},
+	{"rclone:", BackendRclone},
+}
+
+func (b BackendType) String() string {
+	switch b {
+	case BackendLocal:
+		return "local"
+	case BackendS3:
+		return "s3"
+	case BackendSFTP:
+		return "sftp"
+	case BackendREST:
+		return "rest"
+	case BackendB2:
+		return "b2"
+	case BackendAzure:
+		return "azure"
+	case BackendGCS:
+		return "gs"
+	case BackendSwift:
+		return "swift"
+	case BackendRclone:
+		return "rclone"
+	}
+	return fmt.Sprintf("BackendType(%d)", int(b))
+}
+
+// parseBackend returns the backend of the repository string and validates its format
+func parseBackend(repo string) (BackendType, error) {
+	if repo == "" {
+		return BackendLocal, fmt.Errorf("%w: empty repository", ErrInvalidRepoString)
+	}
+
+	backend, location := BackendLocal, repo
+	for _, p := range backendPrefixes {
+		if strings.HasPrefix(repo, p.prefix) {
+			backend, location = p.backend, strings.TrimPrefix(repo, p.prefix)
+			break
+		}
+	}
+
+	invalid := func(format string) (BackendType, error) {
+		return backend, fmt.Errorf("%w: %s repository '%s' must have the format %s", ErrInvalidRepoString, backend, repo, format)
+	}
+
+	switch backend {
+	case BackendLocal:
+		if location == "" {
+			return invalid("/path/to/repo")
+		}
+
+	case BackendS3:
+		// s3:host/bucket[/prefix] or s3:http(s)://host/bucket[/prefix]
+		location = strings.TrimPrefix(strings.TrimPrefix(location, "https://"), "http://")
+		parts := strings.SplitN(location, "/", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return invalid("s3:host/bucket[/prefix]")
+		}
+
+	case BackendSFTP:
+		// sftp:user@host:/path or sftp://user@host[:port]//path
+		if strings.HasPrefix(location, "//") {
+			u, err := url.Parse("sftp:" + location)
+			if err != nil || u.Host == "" || u.Path == "" {
+				return invalid("sftp://[user@]host[:port]//path")
+			}
+			break
+		}
+		host, path, found := strings.Cut(location, ":")
+		if !found || host == "" || path == "" {
+			return invalid("sftp:[user@]host:/path")
+		}
+
+	case BackendREST:
+		u, err := url.Parse(location)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return invalid("rest:http(s)://host[:port]/[path]")
+		}
+
+	case BackendB2, BackendAzure, BackendGCS, BackendSwift:
+		// b2:bucket[:path], azure:container:/path, gs:bucket:/path, swift:container:/path
+		name, _, _ := strings.Cut(location, ":")
+		if name == "" {
+			return invalid(backend.String() + ":bucket[:/path]")
+		}
+
+	case BackendRclone:
+		if location == "" {
+			return invalid("rclone:remote:path")
+		}
+	}
+
+	return backend, nil
+}
+
+// checkCreatable checks the local repository path is an existing directory or can be created
+func checkCreatable(path string) error {
+	path = filepath.Clean(path)
+	for {
+		fi, err := os.Stat(path)
+		if err == nil {
+			if !fi.IsDir() {
+				return fmt.Errorf("%w: '%s' is not a directory", ErrInvalidRepoString, path)
+			}
+			return nil
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return nil
+		}
+		path = parent
+	}
+}
@@ -1,22 +1,64 @@
 package backup
 
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/alexjoedt/go-restic-wrapper/progress"
+)
+
+// Compression levels of restic
+const (
+	CompressionAuto = "auto"
+	CompressionMax  = "max"
+	CompressionOff  = "off"
+)
+
+// ValidCompression reports whether level is a compression level supported by restic
+func ValidCompression(level string) bool {
+	switch level {
+	case CompressionAuto, CompressionMax, CompressionOff:
+		return true
+	}
+	return false
+}
+
 type OptionFunc func(opts *options)
 
 type options struct {
-	host    string
-	path    string
-	tags    []string
-	exclude []string
-	include []string
+	host         string
+	path         string
+	tags         []string
+	meta         map[string]string
+	exclude      []string
+	include      []string
+	iexclude     []string
+	excludeFiles []string
+	excludeCache bool
+	oneFS        bool
+	maxFileSize  *string
+	parent       string
+	stdinName    string
+	filesFrom    []string
+	filesFromV   []string
+	filesFromRaw []string
+	compression  string
+	readConc     uint
+	force        bool
+	symlinkRoot  bool
+	noScan       bool
+	dryRun       bool
+	progress     func(progress.Status)
 }
 
 func Args(opts ...OptionFunc) []string {
-	var options options
-	for _, opt := range opts {
-		opt(&options)
-	}
+	return parse(opts...).args()
+}
 
-	return options.args()
+// ExcludeFiles returns the exclude files set by the options
+func ExcludeFiles(opts ...OptionFunc) []string {
+	return parse(opts...).excludeFiles
 }
 
 func WithTags(tags ...string) OptionFunc {
@@ -25,6 +67,34 @@ func WithTags(tags ...string) OptionFunc {
 	}
 }
 
+// WithMeta stores a key value pair as "key=value" tag on the snapshot,
+// they are read back with Snapshot.Meta. The key must not contain a "="
+// and neither key nor value may contain a ",", which restic uses to separate tags.
+// The backup fails with ErrInvalidMeta otherwise.
+func WithMeta(key string, value string) OptionFunc {
+	return func(opts *options) {
+		opts.tags = append(opts.tags, key+"="+value)
+		if opts.meta == nil {
+			opts.meta = make(map[string]string)
+		}
+		opts.meta[key] = value
+	}
+}
+
+// Meta returns the key value pairs set by WithMeta.
+func Meta(opts ...OptionFunc) map[string]string {
+	return parse(opts...).meta
+}
+
+// ValidMeta reports whether key and value can be stored as tag by WithMeta
+func ValidMeta(key string, value string) bool {
+	return key != "" && !strings.ContainsAny(key, "=,") && !strings.Contains(value, ",")
+}
+
+// WithIncludes has no effect, restic backup has no include patterns.
+//
+// Deprecated: use WithFilesFrom to back up only the listed files and patterns,
+// or WithPath to back up only a path inside the source.
 func WithIncludes(includes ...string) OptionFunc {
 	return func(opts *options) {
 		opts.include = append(opts.include, includes...)
@@ -37,18 +107,223 @@ func WithExcludes(excludes ...string) OptionFunc {
 	}
 }
 
+// WithIExcludes is like WithExcludes but matches the patterns case-insensitively
+func WithIExcludes(excludes ...string) OptionFunc {
+	return func(opts *options) {
+		opts.iexclude = append(opts.iexclude, excludes...)
+	}
+}
+
+// WithExcludeFile reads exclude patterns from the given files.
+// Relative paths are resolved against the current working directory.
+// restic reads the files on every backup, so changes take effect with the next backup.
+func WithExcludeFile(paths ...string) OptionFunc {
+	return func(opts *options) {
+		for _, p := range paths {
+			opts.excludeFiles = append(opts.excludeFiles, absPath(p))
+		}
+	}
+}
+
+// WithExcludeCaches excludes directories containing a valid CACHEDIR.TAG file
+func WithExcludeCaches() OptionFunc {
+	return func(opts *options) {
+		opts.excludeCache = true
+	}
+}
+
+// WithOneFileSystem excludes other file systems mounted below the source
+func WithOneFileSystem() OptionFunc {
+	return func(opts *options) {
+		opts.oneFS = true
+	}
+}
+
+// ExcludeLargerThan returns the size set by WithExcludeLargerThan and whether it is set.
+func ExcludeLargerThan(opts ...OptionFunc) (string, bool) {
+	options := parse(opts...)
+	if options.maxFileSize == nil {
+		return "", false
+	}
+	return *options.maxFileSize, true
+}
+
+// WithExcludeLargerThan excludes files larger than size, e.g. "500M" or "2G".
+// The backup fails with ErrInvalidSize if the size is empty.
+func WithExcludeLargerThan(size string) OptionFunc {
+	return func(opts *options) {
+		size = strings.TrimSpace(size)
+		opts.maxFileSize = &size
+	}
+}
+
+// Parent returns the parent snapshot set by WithParent, empty if unset.
+func Parent(opts ...OptionFunc) string {
+	return parse(opts...).parent
+}
+
+// WithParent uses the snapshot as parent instead of the latest snapshot of the same host and paths.
+// Unchanged files of the parent aren't read again.
+func WithParent(snapshotID string) OptionFunc {
+	return func(opts *options) {
+		opts.parent = snapshotID
+	}
+}
+
+// WithForceRescan reads all files again instead of skipping files unchanged since the parent snapshot
+func WithForceRescan() OptionFunc {
+	return func(opts *options) {
+		opts.force = true
+	}
+}
+
+// StdinFilename returns the filename set by WithStdinFilename, empty if unset.
+func StdinFilename(opts ...OptionFunc) string {
+	return parse(opts...).stdinName
+}
+
+// WithStdinFilename sets the name of the file the data read by restic.BackupStdin is stored as,
+// restic defaults to "stdin"
+func WithStdinFilename(name string) OptionFunc {
+	return func(opts *options) {
+		opts.stdinName = name
+	}
+}
+
+// FilesFrom reports whether any of the files-from options is set in opts.
+func FilesFrom(opts ...OptionFunc) bool {
+	options := parse(opts...)
+	return len(options.filesFrom)+len(options.filesFromV)+len(options.filesFromRaw) > 0
+}
+
+// WithFilesFrom backs up the files and patterns listed line by line in the file
+// instead of the source path. Relative entries are resolved against the source path,
+// a relative path of the file itself against the current working directory.
+func WithFilesFrom(path string) OptionFunc {
+	return func(opts *options) {
+		opts.filesFrom = append(opts.filesFrom, absPath(path))
+	}
+}
+
+// WithFilesFromVerbatim is like WithFilesFrom but the lines are used as file names without
+// expanding patterns
+func WithFilesFromVerbatim(path string) OptionFunc {
+	return func(opts *options) {
+		opts.filesFromV = append(opts.filesFromV, absPath(path))
+	}
+}
+
+// WithFilesFromRaw is like WithFilesFromVerbatim but the file names are separated by NUL bytes,
+// e.g. the output of find -print0
+func WithFilesFromRaw(path string) OptionFunc {
+	return func(opts *options) {
+		opts.filesFromRaw = append(opts.filesFromRaw, absPath(path))
+	}
+}
+
+// Compression returns the compression level set by WithCompression, empty if unset.
+func Compression(opts ...OptionFunc) string {
+	return parse(opts...).compression
+}
+
+// WithCompression sets the compression level of the backup, overriding the level of the repository.
+// See CompressionAuto, CompressionMax and CompressionOff.
+func WithCompression(level string) OptionFunc {
+	return func(opts *options) {
+		opts.compression = level
+	}
+}
+
+// WithReadConcurrency sets the number of files read concurrently, restic defaults to 2
+func WithReadConcurrency(n uint) OptionFunc {
+	return func(opts *options) {
+		opts.readConc = n
+	}
+}
+
+// SymlinkRoot reports whether WithSymlinkRoot is set in opts.
+func SymlinkRoot(opts ...OptionFunc) bool {
+	return parse(opts...).symlinkRoot
+}
+
+// WithSymlinkRoot backs up a source path which is a symlink as the symlink itself.
+// By default the symlink is followed and the directory it points to is backed up,
+// recorded under its resolved path.
+func WithSymlinkRoot() OptionFunc {
+	return func(opts *options) {
+		opts.symlinkRoot = true
+	}
+}
+
+// WithNoScan skips the initial scan of the source, which estimates the total size.
+// The backup starts immediately, but the progress status has no totals, percentage or ETA.
+func WithNoScan() OptionFunc {
+	return func(opts *options) {
+		opts.noScan = true
+	}
+}
+
+// DryRun reports whether WithDryRun is set in opts.
+func DryRun(opts ...OptionFunc) bool {
+	return parse(opts...).dryRun
+}
+
+// WithDryRun runs the backup without writing to the repository.
+// The summary still reports what would have been added.
+func WithDryRun() OptionFunc {
+	return func(opts *options) {
+		opts.dryRun = true
+	}
+}
+
+// Progress returns the progress callback set by WithProgress, nil if unset.
+func Progress(opts ...OptionFunc) func(progress.Status) {
+	return parse(opts...).progress
+}
+
+// WithProgress calls fn for every progress status restic emits while the backup is running.
+// fn is called sequentially from a separate goroutine and not anymore once the context is done.
+func WithProgress(fn func(progress.Status)) OptionFunc {
+	return func(opts *options) {
+		opts.progress = fn
+	}
+}
+
 func WithHost(host string) OptionFunc {
 	return func(opts *options) {
 		opts.host = host
 	}
 }
 
+// Path returns the path set by WithPath, empty if unset.
+func Path(opts ...OptionFunc) string {
+	return parse(opts...).path
+}
+
+// WithPath backs up only the given path relative to the backup source instead of the whole source.
 func WithPath(path string) OptionFunc {
 	return func(opts *options) {
 		opts.path = path
 	}
 }
 
+// absPath returns p resolved against the current working directory, restic runs in the
+// backup source instead
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+func parse(opts ...OptionFunc) options {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
 func (opts options) args() []string {
 	args := make([]string, 0)
 
@@ -64,5 +339,61 @@ func (opts options) args() []string {
 		args = append(args, "--exclude", exclude)
 	}
 
+	for _, exclude := range opts.iexclude {
+		args = append(args, "--iexclude", exclude)
+	}
+
+	for _, f := range opts.excludeFiles {
+		args = append(args, "--exclude-file", f)
+	}
+
+	for _, f := range opts.filesFrom {
+		args = append(args, "--files-from", f)
+	}
+
+	for _, f := range opts.filesFromV {
+		args = append(args, "--files-from-verbatim", f)
+	}
+
+	for _, f := range opts.filesFromRaw {
+		args = append(args, "--files-from-raw", f)
+	}
+
+	if opts.compression != "" {
+		args = append(args, "--compression", opts.compression)
+	}
+
+	if opts.readConc > 0 {
+		args = append(args, "--read-concurrency", strconv.FormatUint(uint64(opts.readConc), 10))
+	}
+
+	if opts.excludeCache {
+		args = append(args, "--exclude-caches")
+	}
+
+	if opts.oneFS {
+		args = append(args, "--one-file-system")
+	}
+
+	if opts.parent != "" {
+		args = append(args, "--parent", opts.parent)
+	}
+
+	if opts.force {
+		args = append(args, "--force")
+	}
+
+	if opts.maxFileSize != nil {
+		args = append(args, "--exclude-larger-than", *opts.maxFileSize)
+	}
+
+	if opts.noScan {
+		args = append(args, "--no-scan")
+	}
+
+	if opts.dryRun {
+		args = append(args, "--dry-run")
+	}
+
 	return args
 }
@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBackupOptions_args(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []OptionFunc
+		want []string
+	}{
+		{
+			name: "no options",
+			opts: nil,
+			want: []string{},
+		},
+		{
+			name: "host, tags and excludes",
+			opts: []OptionFunc{WithHost("web1"), WithTags("daily"), WithExcludes("*.tmp")},
+			want: []string{"--host", "web1", "--tag", "daily", "--exclude", "*.tmp"},
+		},
+		{
+			name: "case-insensitive excludes",
+			opts: []OptionFunc{WithIExcludes("*.TMP")},
+			want: []string{"--iexclude", "*.TMP"},
+		},
+		{
+			name: "includes are not emitted",
+			opts: []OptionFunc{WithIncludes("*.go")},
+			want: []string{},
+		},
+		{
+			name: "path is not emitted",
+			opts: []OptionFunc{WithPath("sub")},
+			want: []string{},
+		},
+		{
+			name: "exclude caches",
+			opts: []OptionFunc{WithExcludeCaches()},
+			want: []string{"--exclude-caches"},
+		},
+		{
+			name: "one file system",
+			opts: []OptionFunc{WithOneFileSystem()},
+			want: []string{"--one-file-system"},
+		},
+		{
+			name: "exclude caches and one file system",
+			opts: []OptionFunc{WithOneFileSystem(), WithExcludeCaches()},
+			want: []string{"--exclude-caches", "--one-file-system"},
+		},
+		{
+			name: "exclude larger than",
+			opts: []OptionFunc{WithExcludeLargerThan(" 2G ")},
+			want: []string{"--exclude-larger-than", "2G"},
+		},
+		{
+			name: "no scan",
+			opts: []OptionFunc{WithNoScan()},
+			want: []string{"--no-scan"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Args(tt.opts...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithExcludeFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs := filepath.Join(t.TempDir(), "excludes")
+
+	opts := []OptionFunc{WithExcludeFile("excludes.txt", abs)}
+	want := []string{
+		"--exclude-file", filepath.Join(wd, "excludes.txt"),
+		"--exclude-file", abs,
+	}
+
+	// the files are passed on every backup, so restic reads their current content
+	for i := 0; i < 2; i++ {
+		if got := Args(opts...); !reflect.DeepEqual(got, want) {
+			t.Errorf("Args() call %d = %q, want %q", i+1, got, want)
+		}
+	}
+
+	if got := ExcludeFiles(opts...); !reflect.DeepEqual(got, []string{want[1], want[3]}) {
+		t.Errorf("ExcludeFiles() = %q, want %q", got, []string{want[1], want[3]})
+	}
+}
+
+func TestWithFilesFrom(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs := filepath.Join(t.TempDir(), "files")
+
+	opts := []OptionFunc{
+		WithFilesFrom("files.txt"),
+		WithFilesFromVerbatim(abs),
+		WithFilesFromRaw(filepath.Join("lists", "files0")),
+	}
+	want := []string{
+		"--files-from", filepath.Join(wd, "files.txt"),
+		"--files-from-verbatim", abs,
+		"--files-from-raw", filepath.Join(wd, "lists", "files0"),
+	}
+
+	if got := Args(opts...); !reflect.DeepEqual(got, want) {
+		t.Errorf("Args() = %q, want %q", got, want)
+	}
+}
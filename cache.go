@@ -0,0 +1,94 @@
+package restic
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	cacheDirRgx     = regexp.MustCompile(`^([0-9a-f]+)\s+(\d+) days? ago\s*(yes)?`)
+	cacheBaseRgx    = regexp.MustCompile(`cache dirs in (.+)$`)
+	cacheRemovedRgx = regexp.MustCompile(`removing (\d+) old cache dirs`)
+)
+
+// CacheDir is the local cache directory of a repository
+type CacheDir struct {
+	// ID is the ID of the repository the cache belongs to
+	ID       string
+	Path     string
+	LastUsed time.Duration
+	// Old is set if the cache hasn't been used for longer than the maximum cache age
+	Old bool
+}
+
+// ListCaches returns the local cache directories of all repositories
+func (r *Repository) ListCaches(ctx context.Context) ([]CacheDir, error) {
+	out, err := r.command(ctx, "", "cache", "--no-size")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCacheDirs(out), nil
+}
+
+// CacheCleanup removes the cache directories not used for longer than maxAge,
+// which is rounded up to full days. restic's default of 30 days is used if maxAge is 0.
+// It returns the number of removed directories.
+func (r *Repository) CacheCleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	args := []string{"cache", "--cleanup"}
+	if maxAge > 0 {
+		days := int(math.Ceil(maxAge.Hours() / 24))
+		args = append(args, "--max-age", strconv.Itoa(days))
+	}
+
+	out, err := r.command(ctx, "", args...)
+	if err != nil {
+		return 0, err
+	}
+
+	m := cacheRemovedRgx.FindStringSubmatch(out)
+	if m == nil {
+		// restic reports "no old cache dirs found"
+		return 0, nil
+	}
+
+	return strconv.Atoi(m[1])
+}
+
+// parseCacheDirs parses the table printed by restic cache
+func parseCacheDirs(out string) []CacheDir {
+	dirs := make([]CacheDir, 0)
+	base := ""
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if m := cacheBaseRgx.FindStringSubmatch(line); m != nil {
+			base = m[1]
+			continue
+		}
+
+		m := cacheDirRgx.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		days, _ := strconv.Atoi(m[2])
+		dirs = append(dirs, CacheDir{
+			ID:       m[1],
+			LastUsed: time.Duration(days) * 24 * time.Hour,
+			Old:      m[3] != "",
+		})
+	}
+
+	if base != "" {
+		for i := range dirs {
+			dirs[i].Path = filepath.Join(base, dirs[i].ID)
+		}
+	}
+
+	return dirs
+}
@@ -0,0 +1,72 @@
+package restic
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/check"
+)
+
+// CheckResult holds the outcome of a check
+type CheckResult struct {
+	// Errors is the number of errors restic reported, e.g. damaged packs or trees
+	Errors int
+	// Warnings is the number of non-critical issues restic reported, e.g. unused packs
+	// which are removed by the next prune
+	Warnings int
+	Duration time.Duration
+}
+
+// Check checks the repository for errors.
+// restic locks the repository exclusively, so Check waits for the commands
+// of this Repository writing to the repository. It is retried like the read-only
+// commands, see WithRetry.
+// The result is returned even if the command failed.
+// restic 0.16 reports the issues only as text, so Errors and Warnings are counted from
+// the lines starting with "error" and "warning" or marked as non-critical.
+func (r *Repository) Check(ctx context.Context, options ...check.OptionFunc) (*CheckResult, error) {
+	args := []string{"check"}
+	args = append(args, check.Args(options...)...)
+
+	// the write lock is held across the retries, which don't take it again
+	if err := r.lockWrite(ctx); err != nil {
+		return nil, err
+	}
+	defer r.unlockWrite()
+
+	start := time.Now()
+	out, err := r.retry(ctx, invocation{}, args...)
+	res := &CheckResult{
+		Duration: time.Since(start),
+	}
+
+	output := out
+	var resticErr *ResticError
+	if errors.As(err, &resticErr) {
+		output += "\n" + resticErr.Stderr
+	}
+	res.Errors, res.Warnings = checkIssues(output)
+
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// checkIssues counts the errors and warnings in the output of restic check
+func checkIssues(out string) (int, int) {
+	errs, warnings := 0, 0
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(line, "error"):
+			errs++
+		case strings.HasPrefix(line, "warning"), strings.Contains(line, "non-critical"):
+			warnings++
+		}
+	}
+	return errs, warnings
+}
@@ -0,0 +1,60 @@
+package check
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	withCache      bool
+	readData       bool
+	readDataSubset string
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithCache uses the local cache instead of downloading the index and tree data again,
+// which speeds up repeated checks of remote repositories.
+// Corruption of the data stored in the cache itself may go unnoticed.
+func WithCache() OptionFunc {
+	return func(opts *options) {
+		opts.withCache = true
+	}
+}
+
+// WithReadData reads and verifies all data blobs
+func WithReadData() OptionFunc {
+	return func(opts *options) {
+		opts.readData = true
+	}
+}
+
+// WithReadDataSubset reads and verifies a subset of the data blobs,
+// e.g. "1/5", "10%" or "500M"
+func WithReadDataSubset(subset string) OptionFunc {
+	return func(opts *options) {
+		opts.readDataSubset = subset
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.withCache {
+		args = append(args, "--with-cache")
+	}
+
+	if opts.readData {
+		args = append(args, "--read-data")
+	}
+
+	if opts.readDataSubset != "" {
+		args = append(args, "--read-data-subset", opts.readDataSubset)
+	}
+
+	return args
+}
@@ -0,0 +1,43 @@
+package restic
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/alexjoedt/go-restic-wrapper/copyopt"
+)
+
+var copySavedRgx = regexp.MustCompile(`^snapshot ([0-9a-f]+) saved$`)
+
+// CopyTo copies the snapshots of the repository to dst and returns the IDs
+// of the snapshots created in dst. Snapshots already present in dst are skipped.
+// Backend variables of r set with WithEnv are only passed if dst doesn't set them as well.
+func (r *Repository) CopyTo(ctx context.Context, dst *Repository, options ...copyopt.OptionFunc) ([]string, error) {
+	args := []string{"copy"}
+	args = append(args, copyopt.Args(options...)...)
+
+	// restic treats the destination as primary repository
+	inv := invocation{env: r.secondaryEnv(dst), write: true}
+
+	out, err := dst.run(ctx, inv, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCopiedIDs(out), nil
+}
+
+// parseCopiedIDs returns the IDs of the "snapshot <id> saved" lines
+func parseCopiedIDs(out string) []string {
+	ids := make([]string, 0)
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		if m := copySavedRgx.FindStringSubmatch(strings.TrimSpace(sc.Text())); m != nil {
+			ids = append(ids, m[1])
+		}
+	}
+
+	return ids
+}
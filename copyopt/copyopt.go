@@ -0,0 +1,64 @@
+package copyopt
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	ids   []string
+	hosts []string
+	paths []string
+	tags  []string
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithSnapshotIDs copies only the given snapshots
+func WithSnapshotIDs(ids ...string) OptionFunc {
+	return func(opts *options) {
+		opts.ids = append(opts.ids, ids...)
+	}
+}
+
+func WithHosts(hosts ...string) OptionFunc {
+	return func(opts *options) {
+		opts.hosts = append(opts.hosts, hosts...)
+	}
+}
+
+func WithPaths(paths ...string) OptionFunc {
+	return func(opts *options) {
+		opts.paths = append(opts.paths, paths...)
+	}
+}
+
+func WithTags(tags ...string) OptionFunc {
+	return func(opts *options) {
+		opts.tags = append(opts.tags, tags...)
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	for _, h := range opts.hosts {
+		args = append(args, "--host", h)
+	}
+
+	for _, p := range opts.paths {
+		args = append(args, "--path", p)
+	}
+
+	for _, t := range opts.tags {
+		args = append(args, "--tag", t)
+	}
+
+	args = append(args, opts.ids...)
+
+	return args
+}
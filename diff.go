@@ -0,0 +1,123 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alexjoedt/go-restic-wrapper/diff"
+)
+
+// Modifiers of a DiffChange
+const (
+	DiffAdded    string = "+"
+	DiffRemoved  string = "-"
+	DiffModified string = "M"
+	DiffType     string = "T"
+	DiffMetadata string = "U"
+	DiffBitrot   string = "?"
+)
+
+// DiffChange is a path which differs between two snapshots
+type DiffChange struct {
+	Path     string `json:"path"`
+	Modifier string `json:"modifier"`
+}
+
+// IsMetadata reports whether the metadata of the path changed, e.g. its mode or owner.
+// restic combines the modifiers, e.g. "TU" if the type changed as well.
+// Metadata changes are only reported with diff.WithMetadata.
+func (c DiffChange) IsMetadata() bool {
+	return strings.Contains(c.Modifier, DiffMetadata)
+}
+
+// DiffStat counts the added or removed items of a diff
+type DiffStat struct {
+	Files     int    `json:"files"`
+	Dirs      int    `json:"dirs"`
+	Others    int    `json:"others"`
+	DataBlobs int    `json:"data_blobs"`
+	TreeBlobs int    `json:"tree_blobs"`
+	Bytes     uint64 `json:"bytes"`
+}
+
+// DiffResult holds the differences between two snapshots
+type DiffResult struct {
+	SourceSnapshot string       `json:"source_snapshot"`
+	TargetSnapshot string       `json:"target_snapshot"`
+	ChangedFiles   int          `json:"changed_files"`
+	Added          DiffStat     `json:"added"`
+	Removed        DiffStat     `json:"removed"`
+	Changes        []DiffChange `json:"-"`
+}
+
+// MetadataChanges returns the paths whose metadata changed
+func (d *DiffResult) MetadataChanges() []DiffChange {
+	changes := make([]DiffChange, 0)
+	for _, c := range d.Changes {
+		if c.IsMetadata() {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// Diff returns the differences between the snapshots with the given IDs
+func (r *Repository) Diff(ctx context.Context, snapshotA string, snapshotB string, options ...diff.OptionFunc) (*DiffResult, error) {
+
+	if !IsSnapshotID(snapshotA) || !IsSnapshotID(snapshotB) {
+		return nil, ErrInvalidID
+	}
+
+	args := r.readOnly("diff", "--json")
+	args = append(args, diff.Args(options...)...)
+	args = append(args, snapshotA, snapshotB)
+
+	out, err := r.query(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDiff(out)
+}
+
+// parseDiff decodes the line delimited JSON output of restic diff
+func parseDiff(out string) (*DiffResult, error) {
+	res := &DiffResult{
+		Changes: make([]DiffChange, 0),
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var msg struct {
+			MessageType string `json:"message_type"`
+		}
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return nil, fmt.Errorf("failed to decode diff output %q: %w", line, err)
+		}
+
+		switch msg.MessageType {
+		case "change":
+			var c DiffChange
+			if err := json.Unmarshal([]byte(line), &c); err != nil {
+				return nil, err
+			}
+			res.Changes = append(res.Changes, c)
+		case "statistics":
+			if err := json.Unmarshal([]byte(line), res); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if res.SourceSnapshot == "" {
+		return nil, errors.New("no statistics in diff output")
+	}
+
+	return res, nil
+}
@@ -0,0 +1,34 @@
+package diff
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	metadata bool
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithMetadata reports changes of the metadata like mode, uid and gid,
+// even if the content of a file didn't change.
+func WithMetadata() OptionFunc {
+	return func(opts *options) {
+		opts.metadata = true
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.metadata {
+		args = append(args, "--metadata")
+	}
+
+	return args
+}
@@ -0,0 +1,31 @@
+package restic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alexjoedt/go-restic-wrapper/dump"
+)
+
+// Dump writes the file at path in the snapshot with the given ID to w.
+// A directory is written as archive, see dump.WithArchive.
+// The content is streamed to w without buffering it in memory.
+func (r *Repository) Dump(ctx context.Context, snapshotID string, path string, w io.Writer, options ...dump.OptionFunc) error {
+
+	if !IsSnapshotID(snapshotID) {
+		return fmt.Errorf("%w: '%s'", ErrInvalidID, snapshotID)
+	}
+
+	if path == "" {
+		return errors.New("empty path")
+	}
+
+	args := r.readOnly("dump")
+	args = append(args, dump.Args(options...)...)
+	args = append(args, snapshotID, path)
+
+	_, err := r.run(ctx, invocation{stdout: w}, args...)
+	return err
+}
@@ -0,0 +1,41 @@
+package dump
+
+type OptionFunc func(opts *options)
+
+// Archive is the format a directory is dumped in
+type Archive string
+
+const (
+	Tar Archive = "tar"
+	Zip Archive = "zip"
+)
+
+type options struct {
+	archive Archive
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithArchive sets the archive format used to dump directories, restic defaults to Tar
+func WithArchive(archive Archive) OptionFunc {
+	return func(opts *options) {
+		opts.archive = archive
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.archive != "" {
+		args = append(args, "--archive", string(opts.archive))
+	}
+
+	return args
+}
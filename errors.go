@@ -0,0 +1,244 @@
+package restic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResticError is returned if a restic command fails.
+// It wraps the error classified from the output, e.g. ErrRepoLocked, so errors.Is still works.
+type ResticError struct {
+	// ExitCode is the exit code of restic, -1 if it didn't exit normally
+	ExitCode int
+	// Command is the restic subcommand, e.g. "backup"
+	Command string
+	// Args are the arguments of the command with secrets redacted
+	Args []string
+	// Stderr is the error output of the command with secrets redacted
+	Stderr string
+	// Err is the classified error, nil if the output is unknown
+	Err error
+}
+
+func (e *ResticError) Error() string {
+	msg := strings.TrimSpace(e.Stderr)
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	return fmt.Sprintf("restic %s failed with exit code %d: %s", e.Command, e.ExitCode, msg)
+}
+
+func (e *ResticError) Unwrap() error {
+	return e.Err
+}
+
+// resticError returns the ResticError of the failed command run with args
+func (r *Repository) resticError(err error, args []string, stdErr string) error {
+	e := &ResticError{
+		ExitCode: -1,
+		Args:     r.redactArgs(args),
+		Stderr:   r.redact(stdErr),
+	}
+	// the errors quote stderr, so they are parsed from the redacted output
+	e.Err = parseStdErr(e.Stderr)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		e.ExitCode = exitErr.ExitCode()
+	}
+
+	// restic 0.17 reports some errors by the exit code as well
+	if e.Err == nil {
+		switch e.ExitCode {
+		case exitCodeNoRepo:
+			e.Err = ErrRepoNotFound
+		case exitCodeLocked:
+			e.Err = ErrRepoLocked
+		case exitCodePassword:
+			e.Err = ErrInvalidPassword
+		}
+	}
+
+	for _, arg := range e.Args {
+		if !strings.HasPrefix(arg, "-") {
+			e.Command = arg
+			break
+		}
+	}
+
+	return e
+}
+
+// LockedError is returned if the repository is locked by another process.
+// It wraps ErrRepoLocked and holds the lock owner restic reported.
+type LockedError struct {
+	PID       int
+	Hostname  string
+	Username  string
+	Exclusive bool
+	Created   time.Time
+	StorageID string
+}
+
+func (e *LockedError) Error() string {
+	mode := "locked"
+	if e.Exclusive {
+		mode = "locked exclusively"
+	}
+	return fmt.Sprintf("repository is already %s by PID %d on %s by %s", mode, e.PID, e.Hostname, e.Username)
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrRepoLocked
+}
+
+var (
+	lockedByRegex    = regexp.MustCompile(`already locked (exclusively )?by PID (\d+) on (\S+) by (.+?) \(UID`)
+	lockCreatedRegex = regexp.MustCompile(`lock was created at (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+	lockStorageRegex = regexp.MustCompile(`storage ID ([0-9a-f]+)`)
+)
+
+// parseLockedError extracts the lock owner from restic's error message.
+// It returns ErrRepoLocked if the owner is not part of the message.
+func parseLockedError(stdErr string) error {
+	m := lockedByRegex.FindStringSubmatch(stdErr)
+	if m == nil {
+		return ErrRepoLocked
+	}
+
+	e := &LockedError{
+		Exclusive: m[1] != "",
+		Hostname:  m[3],
+		Username:  m[4],
+	}
+	e.PID, _ = strconv.Atoi(m[2])
+
+	if c := lockCreatedRegex.FindStringSubmatch(stdErr); c != nil {
+		e.Created, _ = time.ParseInLocation("2006-01-02 15:04:05", c[1], time.Local)
+	}
+
+	if id := lockStorageRegex.FindStringSubmatch(stdErr); id != nil {
+		e.StorageID = id[1]
+	}
+
+	return e
+}
+
+// Exit codes of restic, the codes above 3 are used since restic 0.17
+const (
+	// exitCodePartial is the exit code of a backup which couldn't read all source files
+	exitCodePartial = 3
+	// exitCodeNoRepo is the exit code if the repository doesn't exist
+	exitCodeNoRepo = 10
+	// exitCodeLocked is the exit code if the repository couldn't be locked
+	exitCodeLocked = 11
+	// exitCodePassword is the exit code of a wrong password
+	exitCodePassword = 12
+)
+
+// PartialBackupError is returned together with the summary if a backup completed,
+// but some source files could not be read. It wraps ErrBackupPartial and the ResticError.
+type PartialBackupError struct {
+	// Files are the files which could not be read
+	Files []string
+	Err   *ResticError
+}
+
+func (e *PartialBackupError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrBackupPartial, strings.Join(e.Files, ", "))
+}
+
+func (e *PartialBackupError) Unwrap() []error {
+	return []error{ErrBackupPartial, e.Err}
+}
+
+// partialBackupError returns the PartialBackupError if err is the ResticError of a partial backup
+func partialBackupError(err error) *PartialBackupError {
+	var resticErr *ResticError
+	if !errors.As(err, &resticErr) || resticErr.ExitCode != exitCodePartial {
+		return nil
+	}
+
+	e := &PartialBackupError{
+		Files: make([]string, 0),
+		Err:   resticErr,
+	}
+
+	// restic reports the unreadable files as JSON error messages on stderr
+	e.Files = errorItems(resticErr.Stderr)
+
+	return e
+}
+
+// errorMessage is a JSON error message restic prints on stderr
+type errorMessage struct {
+	MessageType string `json:"message_type"`
+	Error       struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	During string `json:"during"`
+	Item   string `json:"item"`
+}
+
+// verifyErrorMessages are the messages of the errors restic restore --verify reports
+var verifyErrorMessages = []string{
+	"invalid file size",
+	"unexpected content",
+}
+
+// isVerifyError reports whether the error was reported while verifying restored files
+func (m errorMessage) isVerifyError() bool {
+	return m.During == "verify" || containsAny(m.Error.Message, verifyErrorMessages...)
+}
+
+// errorMessages returns the JSON error messages restic printed on stderr
+func errorMessages(stdErr string) []errorMessage {
+	msgs := make([]errorMessage, 0)
+	for _, line := range strings.Split(stdErr, "\n") {
+		var msg errorMessage
+		if json.Unmarshal([]byte(line), &msg) != nil || msg.MessageType != "error" {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
+
+// errorItems returns the items of the JSON error messages restic printed on stderr
+func errorItems(stdErr string) []string {
+	items := make([]string, 0)
+	for _, msg := range errorMessages(stdErr) {
+		if msg.Item != "" {
+			items = append(items, msg.Item)
+		}
+	}
+
+	return items
+}
+
+// IsRetryable reports whether the operation which returned err may succeed when retried.
+// Network errors and locked repositories are retryable, configuration errors
+// like a wrong password, an invalid ID or a missing repository are permanent.
+// Besides the sentinel errors the exit code of a *ResticError is considered.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrNetwork), errors.Is(err, ErrRepoLocked):
+		return true
+	}
+
+	var resticErr *ResticError
+	if errors.As(err, &resticErr) && resticErr.ExitCode == exitCodeLocked {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package restic
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestParseStdErr(t *testing.T) {
+	tests := []struct {
+		name      string
+		stdErr    string
+		want      error
+		retryable bool
+	}{
+		{
+			name: "flaky backend",
+			stdErr: "Load(<data/6d5e4f3a2b>, 0, 0) returned error, retrying after 1.207s: read tcp 10.0.0.2:51234->10.0.0.1:443: read: connection reset by peer\n" +
+				"Fatal: unable to load snapshot: read tcp 10.0.0.2:51234->10.0.0.1:443: read: connection reset by peer",
+			want:      ErrNetwork,
+			retryable: true,
+		},
+		{
+			name:      "no matching ID",
+			stdErr:    "Fatal: no matching ID found for prefix \"deadbeef\"",
+			want:      ErrInvalidID,
+			retryable: false,
+		},
+		{
+			name:      "locked",
+			stdErr:    "unable to create lock in backend: repository is already locked by PID 4242 on host by user (UID 0, GID 0)",
+			want:      ErrRepoLocked,
+			retryable: true,
+		},
+		{
+			name:      "wrong password",
+			stdErr:    "Fatal: wrong password or no key found",
+			want:      ErrInvalidPassword,
+			retryable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseStdErr(tt.stdErr)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("parseStdErr() = %v, want %v", err, tt.want)
+			}
+
+			r := Open("/tmp/repo", "secret")
+			resticErr := r.resticError(errors.New("exit status 1"), []string{"snapshots"}, tt.stdErr)
+			if got := IsRetryable(resticErr); got != tt.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestResticError_exitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("exit codes are set with sh")
+	}
+
+	tests := []struct {
+		code      int
+		want      error
+		retryable bool
+	}{
+		{code: 1, want: nil, retryable: false},
+		{code: exitCodeNoRepo, want: ErrRepoNotFound, retryable: false},
+		{code: exitCodeLocked, want: ErrRepoLocked, retryable: true},
+		{code: exitCodePassword, want: ErrInvalidPassword, retryable: false},
+	}
+
+	r := Open("/tmp/repo", "secret")
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.code), func(t *testing.T) {
+			exitErr := exec.Command("sh", "-c", "exit "+strconv.Itoa(tt.code)).Run()
+
+			// the output of restic is not recognized, only the exit code
+			err := r.resticError(exitErr, []string{"backup"}, "Fatal: something unexpected")
+
+			var resticErr *ResticError
+			if !errors.As(err, &resticErr) || resticErr.ExitCode != tt.code {
+				t.Fatalf("resticError() = %#v, want exit code %d", err, tt.code)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("resticError() = %v, want %v", err, tt.want)
+			}
+			if got := IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.retryable)
+			}
+		})
+	}
+}
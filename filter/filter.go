@@ -1,31 +1,70 @@
 package filter
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type OptionFunc func(opts *options)
 
 type options struct {
-	hosts  []string
-	paths  []string
-	tags   []string
-	latest uint
+	hosts    []string
+	paths    []string
+	tags     []string
+	latest   uint
+	original string
+	from     time.Time
+	to       time.Time
+	ids      []string
+
+	groupBy    []string
+	groupBySet bool
 }
 
 func Args(opts ...OptionFunc) []string {
-	var options options
-	for _, opt := range opts {
-		opt(&options)
-	}
+	return parse(opts...).args()
+}
+
+// Snapshot holds the snapshot attributes the filters are matched against.
+type Snapshot struct {
+	Hostname string
+	Paths    []string
+	Tags     []string
+	Original string
+	Time     time.Time
+}
+
+// Match reports whether the snapshot s satisfies the filters the same way restic does.
+// WithLatest can't be evaluated on a single snapshot and is ignored, as is WithIDs.
+func Match(s Snapshot, opts ...OptionFunc) bool {
+	options := parse(opts...)
+	return options.matchArgs(s) && options.matchClientSide(s)
+}
 
-	return options.args()
+// MatchClientSide reports whether the snapshot s satisfies the filters which are
+// applied client-side. Filters passed to restic as arguments are not evaluated.
+func MatchClientSide(s Snapshot, opts ...OptionFunc) bool {
+	return parse(opts...).matchClientSide(s)
 }
 
+// WithTags emits a separate --tag flag per tag, which selects snapshots having any of the tags.
 func WithTags(tags ...string) OptionFunc {
 	return func(opts *options) {
 		opts.tags = append(opts.tags, tags...)
 	}
 }
 
+// WithTagGroup emits the tags as a single comma separated --tag flag,
+// which selects snapshots having all of the tags.
+func WithTagGroup(tags ...string) OptionFunc {
+	return func(opts *options) {
+		if len(tags) > 0 {
+			opts.tags = append(opts.tags, strings.Join(tags, ","))
+		}
+	}
+}
+
 func WithHosts(hosts ...string) OptionFunc {
 	return func(opts *options) {
 		opts.hosts = append(opts.hosts, hosts...)
@@ -38,12 +77,77 @@ func WithPaths(paths ...string) OptionFunc {
 	}
 }
 
+// WithLatest selects the latest no snapshots of each group, by default of each host and paths,
+// see WithGroupBy. It's not a limit of the total number of snapshots.
+// All supported restic versions spell the flag --latest.
 func WithLatest(no uint) OptionFunc {
 	return func(opts *options) {
 		opts.latest = no
 	}
 }
 
+// WithLast selects only the most recent snapshot of each group, it's the same as WithLatest(1).
+func WithLast() OptionFunc {
+	return WithLatest(1)
+}
+
+// GroupBy returns the fields set by WithGroupBy, empty without grouping.
+func GroupBy(opts ...OptionFunc) []string {
+	return parse(opts...).groupBy
+}
+
+// WithGroupBy groups the snapshots by the fields "host", "paths" and "tags".
+func WithGroupBy(fields ...string) OptionFunc {
+	return func(opts *options) {
+		opts.groupBy = fields
+		opts.groupBySet = true
+	}
+}
+
+// WithNoGrouping disables the grouping of snapshots by emitting an empty --group-by,
+// which returns a flat list of all snapshots.
+func WithNoGrouping() OptionFunc {
+	return func(opts *options) {
+		opts.groupBy = nil
+		opts.groupBySet = true
+	}
+}
+
+// WithOriginal selects snapshots which were rewritten from the snapshot with the given ID.
+// Short IDs are matched as prefix. The filter is applied client-side after fetching.
+func WithOriginal(id string) OptionFunc {
+	return func(opts *options) {
+		opts.original = id
+	}
+}
+
+// Hosts returns the hosts set by WithHosts.
+func Hosts(opts ...OptionFunc) []string {
+	return parse(opts...).hosts
+}
+
+// IDs returns the snapshot IDs set by WithIDs.
+func IDs(opts ...OptionFunc) []string {
+	return parse(opts...).ids
+}
+
+// WithIDs selects only the snapshots with the given IDs
+func WithIDs(ids ...string) OptionFunc {
+	return func(opts *options) {
+		opts.ids = append(opts.ids, ids...)
+	}
+}
+
+// WithTimeRange selects snapshots taken between from and to, both inclusive.
+// A zero from or to leaves the range open on that side.
+// The filter is applied client-side after fetching.
+func WithTimeRange(from time.Time, to time.Time) OptionFunc {
+	return func(opts *options) {
+		opts.from = from
+		opts.to = to
+	}
+}
+
 func (opts options) args() []string {
 	args := make([]string, 0)
 
@@ -63,5 +167,91 @@ func (opts options) args() []string {
 		args = append(args, "--latest", fmt.Sprintf("%d", opts.latest))
 	}
 
+	if opts.groupBySet {
+		args = append(args, "--group-by", strings.Join(opts.groupBy, ","))
+	}
+
+	args = append(args, opts.ids...)
+
 	return args
 }
+
+func parse(opts ...OptionFunc) options {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// matchArgs evaluates the filters which are passed to restic as arguments
+func (opts options) matchArgs(s Snapshot) bool {
+	if len(opts.hosts) > 0 && !contains(opts.hosts, s.Hostname) {
+		return false
+	}
+
+	// the snapshot must contain all paths
+	for _, p := range opts.paths {
+		if !contains(s.Paths, p) {
+			return false
+		}
+	}
+
+	// the snapshot must contain all tags of any tag group
+	if len(opts.tags) > 0 {
+		matched := false
+		for _, group := range opts.tags {
+			if hasTags(s.Tags, strings.Split(group, ",")) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchClientSide evaluates the filters which are not supported by restic
+func (opts options) matchClientSide(s Snapshot) bool {
+	if opts.original != "" {
+		if s.Original == "" || !strings.HasPrefix(s.Original, opts.original) {
+			return false
+		}
+	}
+
+	if !opts.from.IsZero() && s.Time.Before(opts.from) {
+		return false
+	}
+
+	if !opts.to.IsZero() && s.Time.After(opts.to) {
+		return false
+	}
+
+	return true
+}
+
+// hasTags reports whether tags contains all wanted tags.
+// An empty tag matches snapshots without tags.
+func hasTags(tags []string, wanted []string) bool {
+	for _, w := range wanted {
+		if w == "" && len(tags) == 0 {
+			return true
+		}
+		if !contains(tags, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
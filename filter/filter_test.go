@@ -0,0 +1,84 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterOptions_args(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []OptionFunc
+		want []string
+	}{
+		{
+			name: "no options",
+			opts: nil,
+			want: []string{},
+		},
+		{
+			name: "group by",
+			opts: []OptionFunc{WithGroupBy("host", "tags")},
+			want: []string{"--group-by", "host,tags"},
+		},
+		{
+			name: "no grouping",
+			opts: []OptionFunc{WithNoGrouping()},
+			want: []string{"--group-by", ""},
+		},
+		{
+			name: "no grouping overrides group by",
+			opts: []OptionFunc{WithGroupBy("host"), WithNoGrouping()},
+			want: []string{"--group-by", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Args(tt.opts...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatch_timeRange(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 31, 23, 59, 59, 0, time.UTC)
+	cest := time.FixedZone("CEST", 2*60*60)
+
+	tests := []struct {
+		name string
+		time time.Time
+		from time.Time
+		to   time.Time
+		want bool
+	}{
+		{name: "inside", time: from.Add(time.Hour), from: from, to: to, want: true},
+		{name: "equal to from", time: from, from: from, to: to, want: true},
+		{name: "equal to to", time: to, from: from, to: to, want: true},
+		{name: "before from", time: from.Add(-time.Nanosecond), from: from, to: to, want: false},
+		{name: "after to", time: to.Add(time.Nanosecond), from: from, to: to, want: false},
+		{name: "open from", time: from.AddDate(-10, 0, 0), to: to, want: true},
+		{name: "open from, after to", time: to.Add(time.Second), to: to, want: false},
+		{name: "open to", time: to.AddDate(10, 0, 0), from: from, want: true},
+		{name: "open to, before from", time: from.Add(-time.Second), from: from, want: false},
+		{name: "open range", time: time.Time{}.Add(time.Hour), want: true},
+		// 01:30 CEST is 23:30 UTC of the previous day
+		{name: "non-UTC before from", time: time.Date(2024, 5, 1, 1, 30, 0, 0, cest), from: from, to: to, want: false},
+		{name: "non-UTC equal to from", time: from.In(cest), from: from, to: to, want: true},
+		{name: "non-UTC bound", time: from, from: time.Date(2024, 5, 1, 2, 0, 0, 0, cest), to: to, want: true},
+		{name: "non-UTC after to", time: time.Date(2024, 6, 1, 1, 59, 59, 1, cest), from: from, to: to, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Snapshot{Time: tt.time}
+			if got := Match(s, WithTimeRange(tt.from, tt.to)); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package forget
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
 
 type OptionFunc func(opts *options)
 
@@ -10,16 +15,47 @@ type options struct {
 	paths    []string
 	tags     []string
 	prune    bool
+	dryRun   bool
 	keepLast uint
+
+	groupBy    []string
+	groupBySet bool
+
+	keepHourly  uint
+	keepDaily   uint
+	keepWeekly  uint
+	keepMonthly uint
+	keepYearly  uint
+	keepWithin  time.Duration
+	keepTags    []string
+
+	keepWithinHourly  time.Duration
+	keepWithinDaily   time.Duration
+	keepWithinWeekly  time.Duration
+	keepWithinMonthly time.Duration
+	keepWithinYearly  time.Duration
 }
 
 func Args(opts ...OptionFunc) []string {
+	return parse(opts...).args()
+}
+
+func parse(opts ...OptionFunc) options {
 	var options options
 	for _, opt := range opts {
 		opt(&options)
 	}
+	return options
+}
 
-	return options.args()
+// SnapshotID returns the snapshot ID set by WithSnapshotID, empty if unset.
+func SnapshotID(opts ...OptionFunc) string {
+	return parse(opts...).id
+}
+
+// Hosts returns the hosts set by WithHosts.
+func Hosts(opts ...OptionFunc) []string {
+	return parse(opts...).hosts
 }
 
 func WithSnapshotID(id string) OptionFunc {
@@ -34,12 +70,30 @@ func WithPrune() OptionFunc {
 	}
 }
 
+// WithDryRun only reports which snapshots would be kept and removed without removing them
+func WithDryRun() OptionFunc {
+	return func(opts *options) {
+		opts.dryRun = true
+	}
+}
+
+// WithTags emits a separate --tag flag per tag, which selects snapshots having any of the tags.
 func WithTags(tags ...string) OptionFunc {
 	return func(opts *options) {
 		opts.tags = append(opts.tags, tags...)
 	}
 }
 
+// WithTagGroup emits the tags as a single comma separated --tag flag,
+// which selects snapshots having all of the tags.
+func WithTagGroup(tags ...string) OptionFunc {
+	return func(opts *options) {
+		if len(tags) > 0 {
+			opts.tags = append(opts.tags, strings.Join(tags, ","))
+		}
+	}
+}
+
 func WithHosts(hosts ...string) OptionFunc {
 	return func(opts *options) {
 		opts.hosts = append(opts.hosts, hosts...)
@@ -58,6 +112,110 @@ func WithKeepLast(no uint) OptionFunc {
 	}
 }
 
+// WithGroupBy groups the snapshots by the fields "host", "paths" and "tags" before the keep
+// rules are applied to each group separately. restic groups by host and paths by default.
+// Without fields all snapshots form a single group, so e.g. WithKeepLast(1) keeps only
+// one snapshot across all hosts.
+func WithGroupBy(fields ...string) OptionFunc {
+	return func(opts *options) {
+		opts.groupBy = fields
+		opts.groupBySet = true
+	}
+}
+
+// WithKeepHourly keeps the last snapshot of each of the last no hours with snapshots
+func WithKeepHourly(no uint) OptionFunc {
+	return func(opts *options) {
+		opts.keepHourly = no
+	}
+}
+
+// WithKeepDaily keeps the last snapshot of each of the last no days with snapshots
+func WithKeepDaily(no uint) OptionFunc {
+	return func(opts *options) {
+		opts.keepDaily = no
+	}
+}
+
+// WithKeepWeekly keeps the last snapshot of each of the last no weeks with snapshots
+func WithKeepWeekly(no uint) OptionFunc {
+	return func(opts *options) {
+		opts.keepWeekly = no
+	}
+}
+
+// WithKeepMonthly keeps the last snapshot of each of the last no months with snapshots
+func WithKeepMonthly(no uint) OptionFunc {
+	return func(opts *options) {
+		opts.keepMonthly = no
+	}
+}
+
+// WithKeepYearly keeps the last snapshot of each of the last no years with snapshots
+func WithKeepYearly(no uint) OptionFunc {
+	return func(opts *options) {
+		opts.keepYearly = no
+	}
+}
+
+// WithKeepWithin keeps all snapshots taken within d before the latest snapshot.
+// d is rounded up to full hours.
+func WithKeepWithin(d time.Duration) OptionFunc {
+	return func(opts *options) {
+		opts.keepWithin = d
+	}
+}
+
+// WithKeepWithinHourly keeps the last snapshot of each hour within d before the latest snapshot
+func WithKeepWithinHourly(d time.Duration) OptionFunc {
+	return func(opts *options) {
+		opts.keepWithinHourly = d
+	}
+}
+
+// WithKeepWithinDaily keeps the last snapshot of each day within d before the latest snapshot
+func WithKeepWithinDaily(d time.Duration) OptionFunc {
+	return func(opts *options) {
+		opts.keepWithinDaily = d
+	}
+}
+
+// WithKeepWithinWeekly keeps the last snapshot of each week within d before the latest snapshot
+func WithKeepWithinWeekly(d time.Duration) OptionFunc {
+	return func(opts *options) {
+		opts.keepWithinWeekly = d
+	}
+}
+
+// WithKeepWithinMonthly keeps the last snapshot of each month within d before the latest snapshot
+func WithKeepWithinMonthly(d time.Duration) OptionFunc {
+	return func(opts *options) {
+		opts.keepWithinMonthly = d
+	}
+}
+
+// WithKeepWithinYearly keeps the last snapshot of each year within d before the latest snapshot
+func WithKeepWithinYearly(d time.Duration) OptionFunc {
+	return func(opts *options) {
+		opts.keepWithinYearly = d
+	}
+}
+
+// WithKeepTags keeps all snapshots having all of the tags,
+// each call adds a separate --keep-tag flag
+func WithKeepTags(tags ...string) OptionFunc {
+	return func(opts *options) {
+		if len(tags) > 0 {
+			opts.keepTags = append(opts.keepTags, strings.Join(tags, ","))
+		}
+	}
+}
+
+// formatDuration formats d rounded up to full hours the way restic parses durations
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%dh", int64(math.Ceil(d.Hours())))
+}
+
 func (opts options) args() []string {
 	args := make([]string, 0)
 
@@ -78,13 +236,66 @@ func (opts options) args() []string {
 		args = append(args, "--tag", t)
 	}
 
+	// the grouping precedes the keep rules applied to each group
+	if opts.groupBySet {
+		args = append(args, "--group-by", strings.Join(opts.groupBy, ","))
+	}
+
 	if opts.keepLast > 0 {
 		args = append(args, "--keep-last", fmt.Sprintf("%d", opts.keepLast))
 	}
 
+	if opts.keepHourly > 0 {
+		args = append(args, "--keep-hourly", fmt.Sprintf("%d", opts.keepHourly))
+	}
+
+	if opts.keepDaily > 0 {
+		args = append(args, "--keep-daily", fmt.Sprintf("%d", opts.keepDaily))
+	}
+
+	if opts.keepWeekly > 0 {
+		args = append(args, "--keep-weekly", fmt.Sprintf("%d", opts.keepWeekly))
+	}
+
+	if opts.keepMonthly > 0 {
+		args = append(args, "--keep-monthly", fmt.Sprintf("%d", opts.keepMonthly))
+	}
+
+	if opts.keepYearly > 0 {
+		args = append(args, "--keep-yearly", fmt.Sprintf("%d", opts.keepYearly))
+	}
+
+	if opts.keepWithin > 0 {
+		args = append(args, "--keep-within", formatDuration(opts.keepWithin))
+	}
+
+	for _, t := range opts.keepTags {
+		args = append(args, "--keep-tag", t)
+	}
+
+	withins := []struct {
+		flag string
+		d    time.Duration
+	}{
+		{"--keep-within-hourly", opts.keepWithinHourly},
+		{"--keep-within-daily", opts.keepWithinDaily},
+		{"--keep-within-weekly", opts.keepWithinWeekly},
+		{"--keep-within-monthly", opts.keepWithinMonthly},
+		{"--keep-within-yearly", opts.keepWithinYearly},
+	}
+	for _, w := range withins {
+		if w.d > 0 {
+			args = append(args, w.flag, formatDuration(w.d))
+		}
+	}
+
 	if opts.prune {
 		args = append(args, "--prune")
 	}
 
+	if opts.dryRun {
+		args = append(args, "--dry-run")
+	}
+
 	return args
 }
@@ -0,0 +1,108 @@
+package forget
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestForgetOptions_args(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []OptionFunc
+		want []string
+	}{
+		{
+			name: "no options",
+			opts: nil,
+			want: []string{},
+		},
+		{
+			name: "keep counts",
+			opts: []OptionFunc{
+				WithKeepLast(1), WithKeepHourly(2), WithKeepDaily(3),
+				WithKeepWeekly(4), WithKeepMonthly(5), WithKeepYearly(6),
+			},
+			want: []string{
+				"--keep-last", "1", "--keep-hourly", "2", "--keep-daily", "3",
+				"--keep-weekly", "4", "--keep-monthly", "5", "--keep-yearly", "6",
+			},
+		},
+		{
+			name: "zero values are not emitted",
+			opts: []OptionFunc{WithKeepDaily(0), WithKeepWithin(0), WithKeepWithinDaily(0), WithKeepTags()},
+			want: []string{},
+		},
+		{
+			name: "keep within",
+			opts: []OptionFunc{WithKeepWithin(30 * 24 * time.Hour)},
+			want: []string{"--keep-within", "720h"},
+		},
+		{
+			name: "keep tags",
+			opts: []OptionFunc{WithKeepTags("important"), WithKeepTags("a", "b")},
+			want: []string{"--keep-tag", "important", "--keep-tag", "a,b"},
+		},
+		{
+			name: "keep within periods",
+			opts: []OptionFunc{
+				WithKeepWithinHourly(48 * time.Hour),
+				WithKeepWithinDaily(7 * 24 * time.Hour),
+				WithKeepWithinWeekly(90 * time.Minute),
+				WithKeepWithinMonthly(365 * 24 * time.Hour),
+				WithKeepWithinYearly(time.Nanosecond),
+			},
+			want: []string{
+				"--keep-within-hourly", "48h",
+				"--keep-within-daily", "168h",
+				"--keep-within-weekly", "2h",
+				"--keep-within-monthly", "8760h",
+				"--keep-within-yearly", "1h",
+			},
+		},
+		{
+			name: "group by precedes the keep rules",
+			opts: []OptionFunc{WithKeepLast(3), WithKeepDaily(7), WithGroupBy("host", "tags")},
+			want: []string{"--group-by", "host,tags", "--keep-last", "3", "--keep-daily", "7"},
+		},
+		{
+			name: "group by without fields",
+			opts: []OptionFunc{WithKeepLast(1), WithGroupBy()},
+			want: []string{"--group-by", "", "--keep-last", "1"},
+		},
+		{
+			name: "group by follows the selection",
+			opts: []OptionFunc{WithKeepWithin(time.Hour), WithGroupBy("paths"), WithHosts("web1")},
+			want: []string{"--host", "web1", "--group-by", "paths", "--keep-within", "1h"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Args(tt.opts...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: time.Hour, want: "1h"},
+		{d: 24 * time.Hour, want: "24h"},
+		{d: time.Nanosecond, want: "1h"},
+		{d: time.Hour + time.Nanosecond, want: "2h"},
+		{d: 90 * time.Minute, want: "2h"},
+		{d: 59 * time.Minute, want: "1h"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package restic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alexjoedt/go-restic-wrapper/backup"
+)
+
+var ErrBackupAborted error = errors.New("backup aborted")
+
+// BackupHandle controls a backup started with StartBackup
+type BackupHandle struct {
+	repo    *Repository
+	proc    *process
+	aborted atomic.Bool
+	dryRun  bool
+	cancel  context.CancelFunc
+
+	waitOnce sync.Once
+	summary  *BackupSummary
+	err      error
+}
+
+// StartBackup starts backing up the given path and returns without waiting for the backup to finish.
+// Call Wait on the returned handle to get the summary. Other commands writing to the
+// repository are blocked until Wait returns.
+func (r *Repository) StartBackup(ctx context.Context, path string, options ...backup.OptionFunc) (*BackupHandle, error) {
+
+	if err := r.prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	dir, args, err := r.backupArgs(path, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+
+	// the write lock is held until Wait returns
+	if err := r.lockWrite(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	inv := invocation{dir: dir, write: true}
+	if fn := backup.Progress(options...); fn != nil {
+		inv.onLine = progressLines(ctx, fn)
+	}
+
+	proc, err := r.startProcess(ctx, inv, args...)
+	if err != nil {
+		r.unlockWrite()
+		cancel()
+		return nil, err
+	}
+
+	return &BackupHandle{
+		repo:   r,
+		proc:   proc,
+		dryRun: backup.DryRun(options...),
+		cancel: cancel,
+	}, nil
+}
+
+// Abort interrupts the backup with SIGINT, so restic can remove its lock and exit cleanly.
+// Abort doesn't produce a partial snapshot, restic saves no snapshot when it's interrupted.
+// The data uploaded so far is kept and reused by the next backup of the same files,
+// which only uploads the rest. Abort doesn't wait for restic to exit, call Wait for that.
+func (h *BackupHandle) Abort() error {
+	h.aborted.Store(true)
+	return h.proc.cmd.Process.Signal(os.Interrupt)
+}
+
+// Wait waits for the backup to finish and returns its summary.
+// If the backup was aborted, no summary is returned and the error wraps ErrBackupAborted.
+// Wait can be called multiple times and always returns the same result.
+func (h *BackupHandle) Wait() (*BackupSummary, error) {
+	h.waitOnce.Do(func() {
+		defer h.cancel()
+		defer h.repo.unlockWrite()
+
+		out, err := h.proc.wait()
+		if err != nil && h.aborted.Load() {
+			h.err = fmt.Errorf("%w: %w", ErrBackupAborted, err)
+			return
+		}
+
+		h.summary, h.err = backupResult(out, err, h.dryRun)
+	})
+
+	return h.summary, h.err
+}
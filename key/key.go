@@ -0,0 +1,45 @@
+package key
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	user string
+	host string
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithUser sets the username stored in the new key
+func WithUser(user string) OptionFunc {
+	return func(opts *options) {
+		opts.user = user
+	}
+}
+
+// WithHost sets the hostname stored in the new key
+func WithHost(host string) OptionFunc {
+	return func(opts *options) {
+		opts.host = host
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.user != "" {
+		args = append(args, "--user", opts.user)
+	}
+
+	if opts.host != "" {
+		args = append(args, "--host", opts.host)
+	}
+
+	return args
+}
@@ -0,0 +1,118 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/key"
+)
+
+// keyTimeFormat is the format restic prints the creation time of keys in local time
+const keyTimeFormat = "2006-01-02 15:04:05"
+
+var keySavedRgx = regexp.MustCompile(`saved new key (?:with ID|as) ([0-9a-f]+)`)
+
+// Key is a key of the repository
+type Key struct {
+	ID       string    `json:"id"`
+	Username string    `json:"userName"`
+	Hostname string    `json:"hostName"`
+	Created  time.Time `json:"-"`
+	// Current is set for the key the repository was opened with
+	Current bool `json:"current"`
+}
+
+// Keys returns the keys of the repository
+func (r *Repository) Keys(ctx context.Context) ([]Key, error) {
+	out, err := r.query(ctx, r.readOnly("key", "list", "--json")...)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []struct {
+		Key
+		Created string `json:"created"`
+	}
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(raw))
+	for _, k := range raw {
+		created, err := time.ParseInLocation(keyTimeFormat, k.Created, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid creation time of key %s: %w", k.ID, err)
+		}
+		k.Key.Created = created
+		keys = append(keys, k.Key)
+	}
+
+	return keys, nil
+}
+
+// AddKey adds a key with the new password and returns its ID.
+// The password is passed in a temporary file readable only by the current user.
+func (r *Repository) AddKey(ctx context.Context, newPassword string, options ...key.OptionFunc) (string, error) {
+	args := []string{"key", "add"}
+	args = append(args, key.Args(options...)...)
+
+	return r.saveKey(ctx, newPassword, args...)
+}
+
+// RemoveKey removes the key. The key the repository was opened with can't be removed.
+func (r *Repository) RemoveKey(ctx context.Context, id string) error {
+	_, err := r.writeCommand(ctx, "key", "remove", id)
+	return err
+}
+
+// saveKey runs the key command with the new password and returns the ID of the saved key
+func (r *Repository) saveKey(ctx context.Context, newPassword string, args ...string) (string, error) {
+	f, err := os.CreateTemp("", "restic-key-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(newPassword)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	args = append(args, "--new-password-file", f.Name())
+	out, err := r.writeCommand(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+
+	m := keySavedRgx.FindStringSubmatch(out)
+	if m == nil {
+		return "", fmt.Errorf("no key ID in restic output: %q", out)
+	}
+
+	return m[1], nil
+}
+
+// ChangePassword replaces the key the repository was opened with by a key with the new password
+// and returns the ID of the new key. On success the repository uses the new password
+// for all further commands, replacing a password file or command.
+func (r *Repository) ChangePassword(ctx context.Context, newPassword string) (string, error) {
+	id, err := r.saveKey(ctx, newPassword, "key", "passwd")
+	if err != nil {
+		return "", err
+	}
+
+	r.credMu.Lock()
+	r.password = newPassword
+	r.passwordFile = ""
+	r.passwordCommand = ""
+	r.credMu.Unlock()
+
+	return id, nil
+}
@@ -0,0 +1,47 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// listTypes are the object types supported by restic list
+var listTypes = []string{"blobs", "packs", "index", "snapshots", "keys", "locks"}
+
+// List returns the IDs of all objects of the given type in the repository.
+// Valid types are blobs, packs, index, snapshots, keys and locks.
+func (r *Repository) List(ctx context.Context, objectType string) ([]string, error) {
+	if !isListType(objectType) {
+		return nil, fmt.Errorf("%w: '%s'", ErrInvalidObjectType, objectType)
+	}
+
+	args := r.readOnly("list", objectType)
+
+	out, err := r.query(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// blobs are listed as "<type> <id>"
+		ids = append(ids, fields[len(fields)-1])
+	}
+
+	return ids, nil
+}
+
+func isListType(t string) bool {
+	for _, lt := range listTypes {
+		if t == lt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Lock is a lock held on the repository
+type Lock struct {
+	ID        string    `json:"-"`
+	Time      time.Time `json:"time"`
+	Exclusive bool      `json:"exclusive"`
+	Hostname  string    `json:"hostname"`
+	Username  string    `json:"username"`
+	PID       int       `json:"pid"`
+	UID       uint32    `json:"uid"`
+	GID       uint32    `json:"gid"`
+}
+
+// Age returns the time since the lock was created
+func (l Lock) Age() time.Duration {
+	return time.Since(l.Time)
+}
+
+// Locks returns the locks currently held on the repository
+func (r *Repository) Locks(ctx context.Context) ([]Lock, error) {
+	ids, err := r.List(ctx, "locks")
+	if err != nil {
+		return nil, err
+	}
+
+	locks := make([]Lock, 0, len(ids))
+	for _, id := range ids {
+		out, err := r.query(ctx, r.readOnly("cat", "lock", id)...)
+		if err != nil {
+			return nil, err
+		}
+
+		var lock Lock
+		if err := json.Unmarshal([]byte(out), &lock); err != nil {
+			return nil, err
+		}
+		lock.ID = id
+
+		locks = append(locks, lock)
+	}
+
+	return locks, nil
+}
+
+// LockAge returns the age of the oldest lock and whether its process still appears alive.
+// The process can only be checked if the lock was created on this host, locks
+// of other hosts are always reported alive. Without locks it returns 0 and false.
+func (r *Repository) LockAge(ctx context.Context) (time.Duration, bool, error) {
+	locks, err := r.Locks(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(locks) == 0 {
+		return 0, false, nil
+	}
+
+	oldest := locks[0]
+	for _, l := range locks[1:] {
+		if l.Time.Before(oldest.Time) {
+			oldest = l
+		}
+	}
+
+	alive := true
+	if hostname, err := os.Hostname(); err == nil && hostname == oldest.Hostname {
+		alive = isProcessAlive(oldest.PID)
+	}
+
+	return oldest.Age(), alive, nil
+}
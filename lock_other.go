@@ -0,0 +1,8 @@
+//go:build !unix
+
+package restic
+
+// isProcessAlive can't check processes on this platform and reports them alive
+func isProcessAlive(pid int) bool {
+	return true
+}
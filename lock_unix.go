@@ -0,0 +1,18 @@
+//go:build unix
+
+package restic
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isProcessAlive reports whether a process with the pid exists
+func isProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,80 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/ls"
+)
+
+// Node is a file, directory or other item in a snapshot
+type Node struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Path        string      `json:"path"`
+	UID         uint32      `json:"uid"`
+	GID         uint32      `json:"gid"`
+	Size        uint64      `json:"size,omitempty"`
+	Mode        os.FileMode `json:"mode,omitempty"`
+	Permissions string      `json:"permissions,omitempty"`
+	ModTime     time.Time   `json:"mtime"`
+	AccessTime  time.Time   `json:"atime"`
+	ChangeTime  time.Time   `json:"ctime"`
+}
+
+// Ls lists the nodes of the snapshot with the given ID.
+// If paths are given, only the nodes below them are listed.
+// Lists the nodes in read only mode (--no-lock)
+func (r *Repository) Ls(ctx context.Context, snapshotID string, paths []string, options ...ls.OptionFunc) ([]Node, error) {
+
+	if !IsSnapshotID(snapshotID) {
+		return nil, fmt.Errorf("%w: '%s'", ErrInvalidID, snapshotID)
+	}
+
+	args := r.readOnly("ls", "--json")
+	args = append(args, ls.Args(options...)...)
+	args = append(args, snapshotID)
+	args = append(args, paths...)
+
+	out, err := r.query(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseNodes(strings.NewReader(out))
+}
+
+// parseNodes decodes the stream of JSON objects restic ls emits,
+// a leading snapshot object followed by the nodes
+func parseNodes(rd io.Reader) ([]Node, error) {
+	nodes := make([]Node, 0)
+
+	dec := json.NewDecoder(rd)
+	for {
+		var msg struct {
+			Node
+			StructType  string `json:"struct_type"`
+			MessageType string `json:"message_type"`
+		}
+
+		err := dec.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode ls output: %w", err)
+		}
+
+		if msg.StructType == "node" || msg.MessageType == "node" {
+			nodes = append(nodes, msg.Node)
+		}
+	}
+
+	return nodes, nil
+}
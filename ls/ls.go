@@ -0,0 +1,45 @@
+package ls
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	recursive bool
+	long      bool
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithRecursive lists the content of the directories below the given paths recursively
+func WithRecursive() OptionFunc {
+	return func(opts *options) {
+		opts.recursive = true
+	}
+}
+
+// WithLong requests the detailed listing of the nodes
+func WithLong() OptionFunc {
+	return func(opts *options) {
+		opts.long = true
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.recursive {
+		args = append(args, "--recursive")
+	}
+
+	if opts.long {
+		args = append(args, "--long")
+	}
+
+	return args
+}
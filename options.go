@@ -0,0 +1,169 @@
+package restic
+
+import (
+	"strconv"
+	"time"
+)
+
+// Option configures a Repository
+type Option func(r *Repository)
+
+// WithResticBinary sets the restic binary used to run the commands.
+// The binary is looked up in $PATH if path contains no path separator.
+func WithResticBinary(path string) Option {
+	return func(r *Repository) {
+		r.bin = path
+	}
+}
+
+// WithResticChecksum pins the hex encoded SHA-256 checksum of the restic binary.
+// The binary is hashed before its first use and no command is run if it doesn't match.
+func WithResticChecksum(sha256hex string) Option {
+	return func(r *Repository) {
+		r.checksum = sha256hex
+	}
+}
+
+// WithoutJSON runs Init without --json. Other commands are not affected: methods
+// returning parsed results always request JSON, and commands like Unlock, Check or Prune
+// are run with their text output.
+func WithoutJSON() Option {
+	return func(r *Repository) {
+		r.noJSON = true
+	}
+}
+
+// WithNiceness runs restic with the given niceness (-20 to 19) to lower its CPU priority.
+// It requires the nice command and is a no-op if it is not available.
+func WithNiceness(n int) Option {
+	return func(r *Repository) {
+		r.nice = &n
+	}
+}
+
+// WithIONice runs restic with the given IO scheduling class (1 realtime, 2 best-effort, 3 idle)
+// and level (0 to 7). It requires the ionice command and is a no-op if it is not available.
+func WithIONice(class int, level int) Option {
+	return func(r *Repository) {
+		r.ionice = &ioPriority{class: class, level: level}
+	}
+}
+
+// WithCopyChunkerParams initializes the repository with the chunker parameters of src,
+// which keeps the deduplication working when snapshots are copied from src.
+// It only applies to Init. Backend variables of src set with WithEnv are passed
+// unless the new repository sets them as well.
+func WithCopyChunkerParams(src *Repository) Option {
+	return func(r *Repository) {
+		r.chunkerSource = src
+	}
+}
+
+// WithPasswordFile reads the repository password from the file at path
+// instead of passing it in the environment. The password argument must be empty then.
+func WithPasswordFile(path string) Option {
+	return func(r *Repository) {
+		r.passwordFile = path
+	}
+}
+
+// WithPasswordCommand gets the repository password from the output of cmd,
+// e.g. to fetch it from a secret manager. The password argument must be empty then.
+func WithPasswordCommand(cmd string) Option {
+	return func(r *Repository) {
+		r.passwordCommand = cmd
+	}
+}
+
+// WithEnv adds the variables to the environment of every command,
+// e.g. AZURE_ACCOUNT_NAME, GOOGLE_APPLICATION_CREDENTIALS or RCLONE_* for other backends.
+// The variables take precedence over the inherited RESTIC_* variables.
+// Multiple calls are merged.
+func WithEnv(env map[string]string) Option {
+	return func(r *Repository) {
+		if r.env == nil {
+			r.env = make(map[string]string)
+		}
+		for k, v := range env {
+			r.env[k] = v
+		}
+	}
+}
+
+// WithCacheDir sets the directory restic stores its local cache in
+func WithCacheDir(path string) Option {
+	return WithEnv(map[string]string{"RESTIC_CACHE_DIR": path})
+}
+
+// WithGlobalFlag adds restic global flags like "--no-cache" or "--limit-upload", "1024"
+// which are inserted before the subcommand of every command
+func WithGlobalFlag(args ...string) Option {
+	return func(r *Repository) {
+		r.globalArgs = append(r.globalArgs, args...)
+	}
+}
+
+// WithBackendOption sets the extended backend option key to value, e.g. "s3.connections", "20"
+func WithBackendOption(key string, value string) Option {
+	return WithGlobalFlag("-o", key+"="+value)
+}
+
+// WithInsecureTLS skips the verification of the TLS certificate of the backend
+func WithInsecureTLS() Option {
+	return WithGlobalFlag("--insecure-tls")
+}
+
+// WithCACert trusts the PEM encoded root certificates in the file at path
+// in addition to the system certificates, e.g. for backends with a private CA
+func WithCACert(path string) Option {
+	return WithGlobalFlag("--cacert", path)
+}
+
+// WithCompression sets the compression level of all data written to the repository,
+// see backup.CompressionAuto, backup.CompressionMax and backup.CompressionOff.
+// Connect and Init return ErrInvalidCompression for other levels.
+func WithCompression(level string) Option {
+	return func(r *Repository) {
+		r.compression = level
+		r.globalArgs = append(r.globalArgs, "--compression", level)
+	}
+}
+
+// WithPackSize sets the target size of the pack files in MiB, restic defaults to 16 MiB.
+// Larger packs reduce the number of requests to high-latency backends.
+func WithPackSize(mb uint) Option {
+	return WithGlobalFlag("--pack-size", strconv.FormatUint(uint64(mb), 10))
+}
+
+// WithRetry runs read-only commands like Snapshots or Stats and Check up to attempts times
+// as long as they fail with a retryable error, see IsRetryable. The delay starts at backoff
+// and doubles with each retry. Commands writing to the repository like Backup are never retried.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(r *Repository) {
+		r.retryAttempts = attempts
+		r.retryBackoff = backoff
+	}
+}
+
+// WithDefaultTimeout limits every command to d unless the context passed to it has a deadline,
+// which always takes precedence. The command is killed when the timeout expires.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(r *Repository) {
+		r.defaultTimeout = d
+	}
+}
+
+// WithLock makes read-only commands like Snapshots, Stats or Ls lock the repository,
+// by default they run without lock (--no-lock).
+func WithLock() Option {
+	return func(r *Repository) {
+		r.lock = true
+	}
+}
+
+// WithNoLock runs read-only commands without locking the repository, which is the default.
+func WithNoLock() Option {
+	return func(r *Repository) {
+		r.lock = false
+	}
+}
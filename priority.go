@@ -0,0 +1,39 @@
+package restic
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// ioPriority is the IO scheduling class and level set with ionice
+type ioPriority struct {
+	class int
+	level int
+}
+
+// withPriority prefixes the command with nice and ionice if configured.
+// Missing tools are skipped, so the command runs with the default priority.
+func (r *Repository) withPriority(name string, args []string) (string, []string) {
+	if r.ionice != nil {
+		if ionice, err := exec.LookPath("ionice"); err == nil {
+			prefix := []string{"-c", strconv.Itoa(r.ionice.class)}
+
+			// the idle class takes no level
+			if r.ionice.class != 3 {
+				prefix = append(prefix, "-n", strconv.Itoa(r.ionice.level))
+			}
+
+			args = append(append(prefix, name), args...)
+			name = ionice
+		}
+	}
+
+	if r.nice != nil {
+		if nice, err := exec.LookPath("nice"); err == nil {
+			args = append([]string{"-n", strconv.Itoa(*r.nice), name}, args...)
+			name = nice
+		}
+	}
+
+	return name, args
+}
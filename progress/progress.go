@@ -0,0 +1,118 @@
+package progress
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// smoothing is the weight of a new sample in the exponential moving averages
+const smoothing float64 = 0.3
+
+// Status is a progress update emitted by restic while a backup or restore is running.
+// FilesDone and BytesDone are set by backups, the restored and skipped counts by restores.
+// PercentDone is nil if restic reports no percentage, e.g. without the initial scan of a backup.
+type Status struct {
+	MessageType      string   `json:"message_type"`
+	SecondsElapsed   uint64   `json:"seconds_elapsed"`
+	SecondsRemaining uint64   `json:"seconds_remaining"`
+	PercentDone      *float64 `json:"percent_done"`
+	TotalFiles       uint64   `json:"total_files"`
+	FilesDone        uint64   `json:"files_done"`
+	FilesRestored    uint64   `json:"files_restored"`
+	FilesSkipped     uint64   `json:"files_skipped"`
+	TotalBytes       uint64   `json:"total_bytes"`
+	BytesDone        uint64   `json:"bytes_done"`
+	BytesRestored    uint64   `json:"bytes_restored"`
+	BytesSkipped     uint64   `json:"bytes_skipped"`
+	ErrorCount       uint64   `json:"error_count"`
+	CurrentFiles     []string `json:"current_files"`
+
+	// ETA is the smoothed estimated time remaining
+	ETA time.Duration `json:"-"`
+	// BytesPerSecond is the smoothed throughput
+	BytesPerSecond float64 `json:"-"`
+}
+
+// Elapsed returns the time elapsed since the command started
+func (s Status) Elapsed() time.Duration {
+	return time.Duration(s.SecondsElapsed) * time.Second
+}
+
+// Percent returns the fraction of the work done between 0 and 1
+// and whether restic reported it
+func (s Status) Percent() (float64, bool) {
+	if s.PercentDone == nil {
+		return 0, false
+	}
+	return *s.PercentDone, true
+}
+
+// Processed returns the bytes processed by the backup or restore so far
+func (s Status) Processed() uint64 {
+	return s.BytesDone + s.BytesRestored + s.BytesSkipped
+}
+
+// Parse decodes a single line of restic's JSON output.
+// It reports false if the line is not a status message.
+func Parse(line []byte) (Status, bool) {
+	var s Status
+	if err := json.Unmarshal(line, &s); err != nil {
+		return Status{}, false
+	}
+
+	if s.MessageType != "status" {
+		return Status{}, false
+	}
+
+	return s, true
+}
+
+// Tracker computes a smoothed ETA and throughput over successive status updates.
+// The zero value is ready to use.
+type Tracker struct {
+	started bool
+	elapsed uint64
+	bytes   uint64
+	rate    float64
+	eta     float64
+}
+
+// Update sets the ETA and BytesPerSecond of s from the history of updates.
+// The ETA reported by restic is preferred, if it is missing the ETA is
+// computed from the throughput.
+func (t *Tracker) Update(s *Status) {
+	processed := s.Processed()
+
+	if !t.started {
+		t.started = true
+		if s.SecondsElapsed > 0 {
+			t.rate = float64(processed) / float64(s.SecondsElapsed)
+		}
+	} else if s.SecondsElapsed > t.elapsed && processed >= t.bytes {
+		sample := float64(processed-t.bytes) / float64(s.SecondsElapsed-t.elapsed)
+		t.rate = ema(t.rate, sample)
+	}
+
+	if s.SecondsElapsed > t.elapsed || t.elapsed == 0 {
+		t.elapsed = s.SecondsElapsed
+		t.bytes = processed
+	}
+
+	eta := float64(s.SecondsRemaining)
+	if eta == 0 && t.rate > 0 && s.TotalBytes > processed {
+		eta = float64(s.TotalBytes-processed) / t.rate
+	}
+
+	if t.eta == 0 {
+		t.eta = eta
+	} else {
+		t.eta = ema(t.eta, eta)
+	}
+
+	s.BytesPerSecond = t.rate
+	s.ETA = time.Duration(t.eta * float64(time.Second)).Round(time.Second)
+}
+
+func ema(avg float64, sample float64) float64 {
+	return smoothing*sample + (1-smoothing)*avg
+}
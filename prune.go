@@ -0,0 +1,109 @@
+package restic
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/prune"
+)
+
+// PruneResult holds the outcome of a prune run
+type PruneResult struct {
+	// BytesRemoved is the size of the data removed from the repository
+	BytesRemoved uint64
+	// PacksRewritten is the number of packs repacked
+	PacksRewritten int
+	// PacksDeleted is the number of packs deleted
+	PacksDeleted int
+	// UnusedAfter is the unused size left after prune
+	UnusedAfter uint64
+	// UnusedAfterPercent is the unused size left after prune in percent of the remaining size
+	UnusedAfterPercent float64
+	// CappedBySize reports whether repacking was limited by the max repack size,
+	// which means prune should be run again to reclaim the remaining space.
+	CappedBySize bool
+	// DryRun reports whether the repository was left unchanged
+	DryRun   bool
+	Duration time.Duration
+}
+
+var (
+	totalPruneRegex     = regexp.MustCompile(`total prune:\s+\d+ blobs / ([0-9.]+ [KMGTi]*B)`)
+	repackPacksRegex    = regexp.MustCompile(`to repack:\s+(\d+) packs`)
+	deletePacksRegex    = regexp.MustCompile(`to delete:\s+(\d+) packs`)
+	unusedAfterRegex    = regexp.MustCompile(`unused size after prune: ([0-9.]+ [KMGTi]*B) \(([0-9.]+)% of remaining size\)`)
+	byteUnitMultipliers = map[string]float64{
+		"B":   1,
+		"KiB": 1 << 10,
+		"MiB": 1 << 20,
+		"GiB": 1 << 30,
+		"TiB": 1 << 40,
+	}
+)
+
+// Prune removes unreferenced data from the repository.
+// The result is parsed from restic's text output, since prune has no JSON output.
+// The result is returned even if the command failed.
+func (r *Repository) Prune(ctx context.Context, options ...prune.OptionFunc) (*PruneResult, error) {
+	// verbose output includes the pack statistics
+	args := []string{"prune", "--verbose"}
+	args = append(args, prune.Args(options...)...)
+
+	start := time.Now()
+	out, err := r.writeCommand(ctx, args...)
+	res := &PruneResult{
+		DryRun:   prune.DryRun(options...),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		return res, err
+	}
+
+	if m := totalPruneRegex.FindStringSubmatch(out); m != nil {
+		res.BytesRemoved = parseBytes(m[1])
+	}
+
+	if m := repackPacksRegex.FindStringSubmatch(out); m != nil {
+		res.PacksRewritten, _ = strconv.Atoi(m[1])
+	}
+
+	if m := deletePacksRegex.FindStringSubmatch(out); m != nil {
+		res.PacksDeleted, _ = strconv.Atoi(m[1])
+	}
+
+	if m := unusedAfterRegex.FindStringSubmatch(out); m != nil {
+		res.UnusedAfter = parseBytes(m[1])
+		res.UnusedAfterPercent, _ = strconv.ParseFloat(m[2], 64)
+	}
+
+	// restic stops repacking silently when the max repack size is reached,
+	// the only hint is the unused data above the tolerated limit
+	if prune.MaxRepackSize(options...) > 0 {
+		percent, size := prune.MaxUnused(options...)
+		if size > 0 {
+			res.CappedBySize = res.UnusedAfter > uint64(size)
+		} else {
+			res.CappedBySize = res.UnusedAfterPercent > percent
+		}
+	}
+
+	return res, nil
+}
+
+// parseBytes parses a size formatted by restic, e.g. "1.234 GiB"
+func parseBytes(s string) uint64 {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return 0
+	}
+
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+
+	return uint64(v * byteUnitMultipliers[fields[1]])
+}
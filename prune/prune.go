@@ -0,0 +1,130 @@
+package prune
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type OptionFunc func(opts *options)
+
+// Size is an amount of bytes
+type Size uint64
+
+const (
+	Byte Size = 1
+	KiB       = 1024 * Byte
+	MiB       = 1024 * KiB
+	GiB       = 1024 * MiB
+	TiB       = 1024 * GiB
+)
+
+// DefaultMaxUnusedPercent is the unused space restic tolerates if no max unused is set
+const DefaultMaxUnusedPercent float64 = 5
+
+type options struct {
+	maxRepackSize       Size
+	maxUnusedPercent    float64
+	maxUnusedSize       Size
+	maxUnusedSet        bool
+	repackCacheableOnly bool
+	dryRun              bool
+}
+
+func Args(opts ...OptionFunc) []string {
+	return parse(opts...).args()
+}
+
+// MaxRepackSize returns the max repack size set by the options, 0 if unset.
+func MaxRepackSize(opts ...OptionFunc) Size {
+	return parse(opts...).maxRepackSize
+}
+
+// MaxUnused returns the tolerated unused space set by the options, either as
+// percentage or as size. It defaults to DefaultMaxUnusedPercent.
+func MaxUnused(opts ...OptionFunc) (float64, Size) {
+	options := parse(opts...)
+	if !options.maxUnusedSet {
+		return DefaultMaxUnusedPercent, 0
+	}
+	return options.maxUnusedPercent, options.maxUnusedSize
+}
+
+// DryRun reports whether WithDryRun is set in opts.
+func DryRun(opts ...OptionFunc) bool {
+	return parse(opts...).dryRun
+}
+
+// WithMaxRepackSize limits the amount of data prune repacks in a single run.
+// Use it to bound the maintenance time; a capped run must be repeated to prune the rest.
+func WithMaxRepackSize(size Size) OptionFunc {
+	return func(opts *options) {
+		opts.maxRepackSize = size
+	}
+}
+
+// WithMaxUnusedPercent tolerates the given percentage of unused space in the repository
+func WithMaxUnusedPercent(percent float64) OptionFunc {
+	return func(opts *options) {
+		opts.maxUnusedPercent = percent
+		opts.maxUnusedSize = 0
+		opts.maxUnusedSet = true
+	}
+}
+
+// WithMaxUnusedSize tolerates the given amount of unused space in the repository
+func WithMaxUnusedSize(size Size) OptionFunc {
+	return func(opts *options) {
+		opts.maxUnusedPercent = 0
+		opts.maxUnusedSize = size
+		opts.maxUnusedSet = true
+	}
+}
+
+// WithRepackCacheableOnly only repacks packs which are cacheable, i.e. tree packs
+func WithRepackCacheableOnly() OptionFunc {
+	return func(opts *options) {
+		opts.repackCacheableOnly = true
+	}
+}
+
+// WithDryRun only reports what would be removed without modifying the repository
+func WithDryRun() OptionFunc {
+	return func(opts *options) {
+		opts.dryRun = true
+	}
+}
+
+func parse(opts ...OptionFunc) options {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	// restic interprets sizes without unit as bytes
+	if opts.maxRepackSize > 0 {
+		args = append(args, "--max-repack-size", fmt.Sprintf("%d", opts.maxRepackSize))
+	}
+
+	if opts.maxUnusedSet {
+		if opts.maxUnusedSize > 0 {
+			args = append(args, "--max-unused", fmt.Sprintf("%d", opts.maxUnusedSize))
+		} else {
+			args = append(args, "--max-unused", strconv.FormatFloat(opts.maxUnusedPercent, 'f', -1, 64)+"%")
+		}
+	}
+
+	if opts.repackCacheableOnly {
+		args = append(args, "--repack-cacheable-only")
+	}
+
+	if opts.dryRun {
+		args = append(args, "--dry-run")
+	}
+
+	return args
+}
@@ -0,0 +1,116 @@
+package restic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	const (
+		password   = "pw-hunter2"
+		command    = "pass show restic/backup"
+		optSecret  = "opt-secret-value"
+		envSecret  = "env-secret-value"
+		envToken   = "env-token-value"
+		urlSecret  = "url-secret-value"
+		notASecret = "us-east-1"
+	)
+	repoURL := "rest:https://user:" + urlSecret + "@backup.example.com/repo"
+
+	withPassword := Open(repoURL, password,
+		WithEnv(map[string]string{
+			"AWS_SECRET_ACCESS_KEY": envSecret,
+			"B2_ACCOUNT_TOKEN":      envToken,
+			"AWS_DEFAULT_REGION":    notASecret,
+		}),
+		WithBackendOption("s3.secret-key", optSecret),
+		WithBackendOption("s3.region", notASecret),
+	)
+	withCommand := Open(repoURL, "", WithPasswordCommand(command))
+
+	secrets := []string{password, command, optSecret, envSecret, envToken, urlSecret}
+
+	tests := []struct {
+		name   string
+		repo   *Repository
+		args   []string
+		stdErr string
+	}{
+		{
+			name:   "password",
+			repo:   withPassword,
+			args:   []string{"key", "add", "--new-password", password},
+			stdErr: "wrong password " + password,
+		},
+		{
+			name:   "password command",
+			repo:   withCommand,
+			args:   []string{"--password-command", command, "snapshots"},
+			stdErr: "running " + command + " failed",
+		},
+		{
+			name:   "password command flag of another repository",
+			repo:   withPassword,
+			args:   []string{"--password-command", "other command", "snapshots"},
+			stdErr: "",
+		},
+		{
+			name:   "secret backend option",
+			repo:   withPassword,
+			args:   withPassword.cmdArgs([]string{"snapshots"}),
+			stdErr: "invalid s3.secret-key=" + optSecret,
+		},
+		{
+			name:   "secret env",
+			repo:   withPassword,
+			args:   []string{"snapshots"},
+			stdErr: "access denied for " + envSecret + " and " + envToken,
+		},
+		{
+			name:   "url password",
+			repo:   withPassword,
+			args:   []string{"-r", repoURL, "snapshots"},
+			stdErr: "Fatal: unable to open repository at " + repoURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.repo.redactArgs(tt.args)
+			stdErr := tt.repo.redact(tt.stdErr)
+
+			var resticErr *ResticError
+			if !errors.As(tt.repo.resticError(errors.New("exit status 1"), tt.args, tt.stdErr), &resticErr) {
+				t.Fatal("resticError() doesn't return a *ResticError")
+			}
+
+			outputs := map[string]string{
+				"redactArgs":       strings.Join(args, " "),
+				"redact":           stdErr,
+				"ResticError.Args": strings.Join(resticErr.Args, " "),
+				"ResticError":      resticErr.Error(),
+			}
+			for name, out := range outputs {
+				for _, secret := range secrets {
+					if strings.Contains(out, secret) {
+						t.Errorf("%s output %q contains secret %q", name, out, secret)
+					}
+				}
+			}
+
+			if strings.Contains(tt.name, "password command flag") && args[1] != redacted {
+				t.Errorf("--password-command value = %q, want %q", args[1], redacted)
+			}
+		})
+	}
+
+	// values which are no secrets are kept
+	args := withPassword.redactArgs(withPassword.cmdArgs([]string{"snapshots"}))
+	if !strings.Contains(strings.Join(args, " "), "s3.region="+notASecret) {
+		t.Errorf("redactArgs() = %q, want s3.region kept", args)
+	}
+	if got := withPassword.redact("region " + notASecret); got != "region "+notASecret {
+		t.Errorf("redact() = %q, want the region kept", got)
+	}
+}
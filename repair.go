@@ -0,0 +1,121 @@
+package restic
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/repair"
+)
+
+var (
+	repairSavedRgx   = regexp.MustCompile(`saved new snapshot ([0-9a-f]+)`)
+	repairRemovedRgx = regexp.MustCompile(`removed old snapshot ([0-9a-f]+)`)
+)
+
+// RepairResult holds the outcome of a repair
+type RepairResult struct {
+	// Messages are the non-empty lines printed by restic
+	Messages []string
+	Duration time.Duration
+}
+
+// RepairSnapshotsResult holds the outcome of a snapshot repair
+type RepairSnapshotsResult struct {
+	RepairResult
+	// Saved are the IDs of the repaired snapshots
+	Saved []string
+	// Removed are the IDs of the removed original snapshots, see repair.WithForget
+	Removed []string
+}
+
+// RepairIndex rebuilds the index of the repository from the pack files.
+// The legacy rebuild-index command isn't used since every supported
+// restic version provides repair index.
+func (r *Repository) RepairIndex(ctx context.Context, options ...repair.IndexOptionFunc) (*RepairResult, error) {
+	args := []string{"repair", "index"}
+	args = append(args, repair.IndexArgs(options...)...)
+
+	return r.repair(ctx, args...)
+}
+
+// RepairSnapshots rewrites snapshots referencing damaged or missing data
+// without the affected files and directories.
+func (r *Repository) RepairSnapshots(ctx context.Context, options ...repair.SnapshotsOptionFunc) (*RepairSnapshotsResult, error) {
+	args := []string{"repair", "snapshots"}
+	args = append(args, repair.SnapshotsArgs(options...)...)
+
+	res, err := r.repair(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	snapRes := &RepairSnapshotsResult{
+		RepairResult: *res,
+		Saved:        make([]string, 0),
+		Removed:      make([]string, 0),
+	}
+	for _, msg := range res.Messages {
+		if m := repairSavedRgx.FindStringSubmatch(msg); m != nil {
+			snapRes.Saved = append(snapRes.Saved, m[1])
+		}
+		if m := repairRemovedRgx.FindStringSubmatch(msg); m != nil {
+			snapRes.Removed = append(snapRes.Removed, m[1])
+		}
+	}
+
+	return snapRes, nil
+}
+
+// RepairPacks salvages the readable blobs of the damaged pack files
+// and removes the pack files from the repository.
+func (r *Repository) RepairPacks(ctx context.Context, packIDs []string) (*RepairResult, error) {
+	if len(packIDs) == 0 {
+		return nil, errors.New("no pack IDs to repair")
+	}
+
+	args := []string{"repair", "packs"}
+	args = append(args, packIDs...)
+
+	return r.repair(ctx, args...)
+}
+
+// repair runs the repair command and collects its messages
+func (r *Repository) repair(ctx context.Context, args ...string) (*RepairResult, error) {
+	start := time.Now()
+	out, err := r.writeCommand(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &RepairResult{
+		Messages: make([]string, 0),
+		Duration: time.Since(start),
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res.Messages = append(res.Messages, line)
+		}
+	}
+
+	return res, nil
+}
+
+// Recover saves the trees not referenced by any snapshot in a new snapshot
+// and returns its ID. It returns ErrNothingToRecover if all trees are referenced.
+func (r *Repository) Recover(ctx context.Context) (string, error) {
+	res, err := r.repair(ctx, "recover")
+	if err != nil {
+		return "", err
+	}
+
+	for _, msg := range res.Messages {
+		if m := repairSavedRgx.FindStringSubmatch(msg); m != nil {
+			return m[1], nil
+		}
+	}
+
+	return "", ErrNothingToRecover
+}
@@ -0,0 +1,71 @@
+package repair
+
+// IndexOptionFunc is an option of repair index
+type IndexOptionFunc func(opts *indexOptions)
+
+// SnapshotsOptionFunc is an option of repair snapshots
+type SnapshotsOptionFunc func(opts *snapshotsOptions)
+
+type indexOptions struct {
+	readAllPacks bool
+}
+
+type snapshotsOptions struct {
+	forget bool
+}
+
+// IndexArgs returns the arguments of repair index
+func IndexArgs(opts ...IndexOptionFunc) []string {
+	var options indexOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// SnapshotsArgs returns the arguments of repair snapshots
+func SnapshotsArgs(opts ...SnapshotsOptionFunc) []string {
+	var options snapshotsOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithReadAllPacks reads all pack files to rebuild the index from scratch
+// instead of reusing the existing index files
+func WithReadAllPacks() IndexOptionFunc {
+	return func(opts *indexOptions) {
+		opts.readAllPacks = true
+	}
+}
+
+// WithForget removes the original snapshots after repairing them,
+// including snapshots which couldn't be repaired
+func WithForget() SnapshotsOptionFunc {
+	return func(opts *snapshotsOptions) {
+		opts.forget = true
+	}
+}
+
+func (opts indexOptions) args() []string {
+	args := make([]string, 0)
+
+	if opts.readAllPacks {
+		args = append(args, "--read-all-packs")
+	}
+
+	return args
+}
+
+func (opts snapshotsOptions) args() []string {
+	args := make([]string, 0)
+
+	if opts.forget {
+		args = append(args, "--forget")
+	}
+
+	return args
+}
@@ -10,51 +10,188 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/alexjoedt/go-restic-wrapper/backup"
 	"github.com/alexjoedt/go-restic-wrapper/filter"
 	"github.com/alexjoedt/go-restic-wrapper/forget"
 	"github.com/alexjoedt/go-restic-wrapper/restore"
+	"github.com/alexjoedt/go-restic-wrapper/unlock"
 )
 
 // TODO:
-// implement support for S3 and Rest
+// implement support for Rest
 
+// Repository is a restic repository, it's safe for concurrent use.
+// Commands writing to the repository like Backup, Forget or Prune are run one
+// after another, read-only commands like Snapshots run concurrently.
 type Repository struct {
-	path     string
-	password string
+	path string
+	// credMu guards the password fields, which are replaced by ChangePassword
+	credMu          sync.RWMutex
+	password        string
+	passwordFile    string
+	passwordCommand string
+	backend         BackendType
+	// env holds additional environment variables, e.g. backend credentials
+	env map[string]string
+	// globalArgs are inserted before the subcommand of every command
+	globalArgs []string
+	// compression is the compression level passed in globalArgs
+	compression string
+	// attempts of read-only commands failing with a retryable error
+	retryAttempts int
+	retryBackoff  time.Duration
+	// defaultTimeout limits commands whose context has no deadline
+	defaultTimeout time.Duration
+
+	bin      string
+	checksum string
+	noJSON   bool
+	nice     *int
+	ionice   *ioPriority
+
+	// chunkerSource is the repository to copy the chunker parameters from on init
+	chunkerSource *Repository
+
+	prepareMu sync.Mutex
+	prepared  bool
+	// configErr is the configuration error of a repository created with Open
+	configErr error
+
+	// lock makes read-only commands lock the repository, see WithLock
+	lock bool
+	// writeSem serializes the commands writing to the repository
+	writeSem chan struct{}
+
+	hookMu      sync.RWMutex
+	commandHook func(ctx context.Context, args []string)
+	resultHook  func(ctx context.Context, args []string, stdout string, stderr string, err error, d time.Duration)
 }
 
-// Connect creates a new instance of a exiting restic repository.
-func Connect(ctx context.Context, repoPath string, password string) (*Repository, error) {
-
+func newRepository(repoPath string, password string, opts ...Option) *Repository {
 	repo := &Repository{
 		path:     repoPath,
 		password: password,
+		bin:      resticBin,
+		writeSem: make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(repo)
 	}
 
-	_, err := repo.Snapshots(ctx)
+	return repo
+}
+
+// validate checks the configuration of the repository
+func (r *Repository) validate() error {
+	backend, err := parseBackend(r.path)
 	if err != nil {
-		return nil, errors.New("failed to connect to restic repo")
+		return err
+	}
+	r.backend = backend
+
+	sources := 0
+	for _, s := range []string{r.password, r.passwordFile, r.passwordCommand} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return ErrPasswordSource
+	}
+
+	if r.compression != "" && !backup.ValidCompression(r.compression) {
+		return fmt.Errorf("%w: '%s'", ErrInvalidCompression, r.compression)
+	}
+
+	return nil
+}
+
+// Connect creates a new instance of a exiting restic repository.
+// It returns the error of Validate, e.g. ErrInvalidPassword or ErrRepoNotFound,
+// if the repository can't be opened.
+func Connect(ctx context.Context, repoPath string, password string, opts ...Option) (*Repository, error) {
+
+	repo := newRepository(repoPath, password, opts...)
+	if err := repo.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.Validate(ctx); err != nil {
+		return nil, err
 	}
 
 	return repo, nil
 }
 
+// Open creates a new instance of a restic repository without accessing it.
+// It is the lazy counterpart of Connect: configuration errors are returned by Validate
+// and every command, use Validate to check the repository is accessible.
+func Open(repoPath string, password string, opts ...Option) *Repository {
+	repo := newRepository(repoPath, password, opts...)
+	repo.configErr = repo.validate()
+
+	return repo
+}
+
+// Validate checks the repository exists and can be opened with the password.
+// Only the config file of the repository is read.
+func (r *Repository) Validate(ctx context.Context) error {
+	if r.configErr != nil {
+		return r.configErr
+	}
+
+	_, err := r.query(ctx, r.readOnly("cat", "config")...)
+	return err
+}
+
 // Init initialize a new restic repository
-func Init(ctx context.Context, repoPath string, password string) (*Repository, error) {
-	repo := &Repository{
-		path:     repoPath,
-		password: password,
+func Init(ctx context.Context, repoPath string, password string, opts ...Option) (*Repository, error) {
+	repo := newRepository(repoPath, password, opts...)
+	if err := repo.validate(); err != nil {
+		return nil, err
+	}
+
+	if repo.backend == BackendLocal {
+		if err := checkCreatable(strings.TrimPrefix(repoPath, "local:")); err != nil {
+			return nil, err
+		}
 	}
 
 	return repo.init(ctx)
 }
 
+// Path returns the location of the repository
+func (r *Repository) Path() string {
+	return r.path
+}
+
+// Backend returns the storage backend of the repository
+func (r *Repository) Backend() BackendType {
+	return r.backend
+}
+
 func (r *Repository) init(ctx context.Context) (*Repository, error) {
-	_, err := r.command(ctx, "", "init")
+	args := []string{"init"}
+	if !r.noJSON {
+		args = append(args, "--json")
+	}
+
+	inv := invocation{write: true}
+	if r.chunkerSource != nil {
+		args = append(args, "--copy-chunker-params")
+		inv.env = r.chunkerSource.secondaryEnv(r)
+	}
+
+	_, err := r.run(ctx, inv, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -62,71 +199,345 @@ func (r *Repository) init(ctx context.Context) (*Repository, error) {
 	return r, nil
 }
 
-// Backup backing up the given path
+// Backup backing up the given path.
+// A local repository located inside the path is excluded from the backup.
+// If the path is a symlink, the directory it points to is backed up unless
+// backup.WithSymlinkRoot is set.
+// With backup.WithFilesFrom only the listed files are backed up and path may be empty.
+// If some files could not be read, the summary is returned together with a
+// *PartialBackupError, which wraps ErrBackupPartial.
 func (r *Repository) Backup(ctx context.Context, path string, options ...backup.OptionFunc) (*BackupSummary, error) {
 
-	// Check the path
-	if path == "" {
-		return nil, errors.New("empty path")
+	dir, args, err := r.backupArgs(path, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := invocation{dir: dir, write: true}
+	if fn := backup.Progress(options...); fn != nil {
+		inv.onLine = progressLines(ctx, fn)
 	}
 
-	// Check the source to backup
-	_, err := os.Stat(path)
+	out, err := r.run(ctx, inv, args...)
+
+	return backupResult(out, err, backup.DryRun(options...))
+}
+
+// BackupStdin backs up the data read from in as a single file, e.g. the output of a database dump.
+// The file is named after backup.WithStdinFilename.
+// It returns ErrStdinOption for the options selecting files to back up,
+// e.g. backup.WithFilesFrom, backup.WithPath or backup.WithSymlinkRoot.
+func (r *Repository) BackupStdin(ctx context.Context, in io.Reader, options ...backup.OptionFunc) (*BackupSummary, error) {
+	switch {
+	case backup.FilesFrom(options...):
+		return nil, fmt.Errorf("%w: files-from", ErrStdinOption)
+	case backup.Path(options...) != "":
+		return nil, fmt.Errorf("%w: path", ErrStdinOption)
+	case backup.SymlinkRoot(options...):
+		return nil, fmt.Errorf("%w: symlink root", ErrStdinOption)
+	}
+
+	if err := validateBackupOptions(options...); err != nil {
+		return nil, err
+	}
+
+	args := []string{"backup", "--json", "--stdin"}
+	if name := backup.StdinFilename(options...); name != "" {
+		args = append(args, "--stdin-filename", name)
+	}
+	args = append(args, backup.Args(options...)...)
+
+	inv := invocation{stdin: in, write: true}
+	if fn := backup.Progress(options...); fn != nil {
+		inv.onLine = progressLines(ctx, fn)
+	}
+
+	out, err := r.run(ctx, inv, args...)
+
+	return backupResult(out, err, backup.DryRun(options...))
+}
+
+// EstimateBackup runs the backup of the given path in dry-run mode and returns
+// the estimated amount of data it would add, without writing to the repository.
+func (r *Repository) EstimateBackup(ctx context.Context, path string, options ...backup.OptionFunc) (*BackupEstimate, error) {
+
+	summary, err := r.Backup(ctx, path, append(options, backup.WithDryRun())...)
 	if err != nil {
 		return nil, err
 	}
 
+	return &BackupEstimate{
+		NewFiles:     summary.FilesNew,
+		ChangedFiles: summary.FilesChanged,
+		NewBytes:     summary.DataAdded,
+		TotalFiles:   summary.TotalFilesProcessed,
+		TotalBytes:   summary.TotalBytesProcessed,
+	}, nil
+}
+
+// backupArgs checks the source path and returns the working dir and the arguments for the backup command
+func (r *Repository) backupArgs(path string, options ...backup.OptionFunc) (string, []string, error) {
+
+	// Check the path, it is optional if the files to backup are listed in files
+	filesFrom := backup.FilesFrom(options...)
+	if path == "" && !filesFrom {
+		return "", nil, errors.New("empty path")
+	}
+
+	// Check the source to backup
+	if path != "" {
+		_, err := os.Stat(path)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := validateBackupOptions(options...); err != nil {
+		return "", nil, err
+	}
+
 	args := []string{"backup", "--json"}
 	args = append(args, backup.Args(options...)...)
-	args = append(args, ".")
 
-	out, err := r.command(ctx, path, args...)
+	// never backup a local repository into itself
+	if repoPath, ok := r.repoInside(path); path != "" && ok {
+		args = append(args, "--exclude", repoPath)
+	}
+
+	// the listed files are the only targets, relative entries are resolved against path
+	if filesFrom {
+		return path, args, nil
+	}
+
+	// restic backs up the target from within the source dir, which follows a symlinked
+	// source. A symlink is backed up as link by running from its parent dir instead.
+	dir, target := path, "."
+	if backup.SymlinkRoot(options...) {
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return "", nil, err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			dir, target = filepath.Dir(path), filepath.Base(path)
+		}
+	}
+
+	// backup only a path inside the source
+	if p := backup.Path(options...); p != "" {
+		target = filepath.Join(target, p)
+	}
+
+	args = append(args, target)
+
+	return dir, args, nil
+}
+
+// validateBackupOptions checks the options shared by all backups
+func validateBackupOptions(options ...backup.OptionFunc) error {
+	if parent := backup.Parent(options...); parent != "" && !IsSnapshotID(parent) {
+		return fmt.Errorf("%w: parent '%s'", ErrInvalidID, parent)
+	}
+
+	if c := backup.Compression(options...); c != "" && !backup.ValidCompression(c) {
+		return fmt.Errorf("%w: '%s'", ErrInvalidCompression, c)
+	}
+
+	if size, ok := backup.ExcludeLargerThan(options...); ok && size == "" {
+		return fmt.Errorf("%w: empty size to exclude larger files", ErrInvalidSize)
+	}
+
+	for k, v := range backup.Meta(options...) {
+		if !backup.ValidMeta(k, v) {
+			return fmt.Errorf("%w: '%s=%s'", ErrInvalidMeta, k, v)
+		}
+	}
+
+	// Check the exclude files are readable, they are read by restic on every run
+	var unreadable []string
+	for _, f := range backup.ExcludeFiles(options...) {
+		file, err := os.Open(f)
+		if err != nil {
+			unreadable = append(unreadable, f)
+			continue
+		}
+		file.Close()
+	}
+	if len(unreadable) > 0 {
+		return fmt.Errorf("%w: %s", ErrExcludeFile, strings.Join(unreadable, ", "))
+	}
+
+	return nil
+}
+
+// repoInside returns the absolute path of the repository if it is a
+// local repository located inside the directory dir
+func (r *Repository) repoInside(dir string) (string, bool) {
+	if r.backend != BackendLocal {
+		return "", false
+	}
+
+	repoPath, err := filepath.Abs(strings.TrimPrefix(r.path, "local:"))
 	if err != nil {
-		return nil, err
+		return "", false
 	}
 
-	res, err := getSummary(out)
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(dir, repoPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return repoPath, true
+}
+
+// backupResult returns the summary of a backup with the output out which failed with err.
+// A partial backup returns the summary together with a *PartialBackupError.
+func backupResult(out string, err error, dryRun bool) (*BackupSummary, error) {
+	var partialErr *PartialBackupError
+	if err != nil {
+		partialErr = partialBackupError(err)
+		if partialErr == nil {
+			return nil, err
+		}
+	}
+
+	summary, err := parseBackupSummary(out)
 	if err != nil {
 		return nil, err
 	}
+	summary.DryRun = dryRun
+
+	if partialErr != nil {
+		return summary, partialErr
+	}
 
+	return summary, nil
+}
+
+// parseBackupSummary extracts the summary from the output of the backup command
+func parseBackupSummary(out string) (*BackupSummary, error) {
 	var summary BackupSummary
-	err = json.Unmarshal(res, &summary)
-	if err != nil {
-		return nil, nil
+	if err := parseSummary(out, &summary); err != nil {
+		return nil, err
 	}
 
 	return &summary, nil
 }
 
+// parseSummary decodes the summary line of the command output into v
+func parseSummary(out string, v any) error {
+	res, err := getSummary(out)
+	if err != nil {
+		return err
+	}
+
+	if len(res) == 0 {
+		return fmt.Errorf("no summary in output %q", out)
+	}
+
+	if err := json.Unmarshal(res, v); err != nil {
+		return fmt.Errorf("failed to decode summary %q: %w", res, err)
+	}
+
+	return nil
+}
+
 // Snapshots returns snapshots from the repository.
 // Fetches Snapshots in read only mode (--no-lock)
+// With filter.WithGroupBy the snapshots of all groups are returned group by group.
 func (r *Repository) Snapshots(ctx context.Context, filters ...filter.OptionFunc) ([]Snapshot, error) {
+	groups, err := r.SnapshotGroups(ctx, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0)
+	for _, g := range groups {
+		snapshots = append(snapshots, g.Snapshots...)
+	}
+
+	return snapshots, nil
+}
 
-	args := []string{"--no-lock", "snapshots", "--json"}
+// SnapshotGroups returns the snapshots grouped by the fields set with filter.WithGroupBy.
+// Without grouping all snapshots are returned in a single group with an empty key.
+// Groups without snapshots matching the client-side filters are omitted.
+func (r *Repository) SnapshotGroups(ctx context.Context, filters ...filter.OptionFunc) ([]SnapshotGroup, error) {
+
+	for _, id := range filter.IDs(filters...) {
+		if !IsSnapshotID(id) {
+			return nil, fmt.Errorf("%w: '%s'", ErrInvalidID, id)
+		}
+	}
+
+	args := r.readOnly("snapshots", "--json")
 	args = append(args, filter.Args(filters...)...)
 
-	sn, err := r.command(ctx, "", args...)
+	sn, err := r.query(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
 
-	var snapshots []Snapshot
-	err = json.Unmarshal([]byte(sn), &snapshots)
+	var groups []SnapshotGroup
+	grouped := len(filter.GroupBy(filters...)) > 0
+	if grouped {
+		err = json.Unmarshal([]byte(sn), &groups)
+	} else {
+		groups = make([]SnapshotGroup, 1)
+		err = json.Unmarshal([]byte(sn), &groups[0].Snapshots)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return snapshots, nil
+	// apply the client-side filters
+	matchedGroups := make([]SnapshotGroup, 0, len(groups))
+	for _, g := range groups {
+		matched := make([]Snapshot, 0, len(g.Snapshots))
+		for _, s := range g.Snapshots {
+			if filter.MatchClientSide(s.filterSnapshot(), filters...) {
+				matched = append(matched, s)
+			}
+		}
+		if len(matched) == 0 && grouped {
+			continue
+		}
+		g.Snapshots = matched
+		matchedGroups = append(matchedGroups, g)
+	}
+
+	return matchedGroups, nil
+}
+
+// SnapshotsSince returns the snapshots created after since.
+// The filters are applied by restic, the time is filtered after fetching the snapshots.
+func (r *Repository) SnapshotsSince(ctx context.Context, since time.Time, filters ...filter.OptionFunc) ([]Snapshot, error) {
+	snapshots, err := r.Snapshots(ctx, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	newer := make([]Snapshot, 0, len(snapshots))
+	for _, s := range snapshots {
+		if s.Time.After(since) {
+			newer = append(newer, s)
+		}
+	}
+
+	return newer, nil
 }
 
 // SnapshotById returns the snapshot with given id from the repository
 func (r *Repository) SnapshotById(ctx context.Context, id string) (*Snapshot, error) {
 
-	args := []string{"snapshots", "--json"}
+	args := r.readOnly("snapshots", "--json")
 	args = append(args, id)
 
-	sn, err := r.command(ctx, "", args...)
+	sn, err := r.query(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -148,49 +559,113 @@ var (
 	idRegex regexp.Regexp = *regexp.MustCompile(`(^latest(:.*)?$|^[0-9a-f]{8}(:.*)?$|^[0-9a-f]{64}(:.*)?$)`)
 )
 
-// Restore restores a specific snapshot
+// Restore restores a specific snapshot.
+// If some files could not be restored, the summary is returned with an error wrapping ErrRestorePartial.
+// If restored files don't match the snapshot, see restore.WithVerify, the error wraps ErrRestoreVerify.
 func (r *Repository) Restore(ctx context.Context, snapshotID string, target string, options ...restore.OptionFunc) (*RestoreSummary, error) {
 	if target == "" {
 		return nil, errors.New("no target path")
 	}
 
+	if snapshotID == "" {
+		return nil, errors.New("empty snapshot id")
+	}
+
+	if !IsSnapshotID(snapshotID) {
+		return nil, errors.New("invalid snapshot ID")
+	}
+
+	// flags which require restic 0.17.0, the version is checked once for all of them
+	var features []string
+	if mode := restore.Overwrite(options...); mode != "" {
+		if !restore.ValidOverwrite(mode) {
+			return nil, fmt.Errorf("%w: '%s'", ErrInvalidOverwrite, mode)
+		}
+		features = append(features, "--overwrite")
+	}
+
+	if restore.Delete(options...) {
+		features = append(features, "--delete")
+	}
+
+	if len(features) > 0 {
+		if err := r.requireVersion(ctx, "0.17.0", "restore "+strings.Join(features, " and ")); err != nil {
+			return nil, err
+		}
+	}
+
+	// the topmost directory created for the target
+	created := ""
 	if !isPathExists(target) {
+		created = topmostMissingDir(target)
 		if err := os.MkdirAll(target, 0755); err != nil {
 			return nil, err
 		}
 	}
 
-	if snapshotID == "" {
-		return nil, errors.New("empty snapshot id")
+	args := []string{"restore", snapshotID, "--target", target, "--json"}
+
+	args = append(args, restore.Args(options...)...)
+
+	inv := invocation{}
+	if mask, ok := restore.Umask(options...); ok {
+		inv.umask = &mask
 	}
 
-	if !isSnapshotID(snapshotID) {
-		return nil, errors.New("invalid snapshot ID")
+	if fn := restore.Progress(options...); fn != nil {
+		inv.onLine = progressLines(ctx, fn)
 	}
 
-	args := []string{"restore", snapshotID, "--target", target, "--json"}
+	out, err := r.run(ctx, inv, args...)
 
-	args = append(args, restore.Args(options...)...)
-	out, err := r.command(ctx, "", args...)
-	if err != nil {
+	// restic fails if some files could not be restored, but still prints the summary
+	var summary RestoreSummary
+	if perr := parseSummary(out, &summary); perr != nil {
+		if err == nil {
+			return nil, perr
+		}
+		if created != "" && restore.CleanupOnCancel(options...) {
+			os.RemoveAll(created)
+		}
 		return nil, err
 	}
 
-	res, err := getSummary(out)
-	if err != nil {
-		return nil, err
+	var resticErr *ResticError
+	if errors.As(err, &resticErr) {
+		verifyErrors, restoreErrors := 0, 0
+		for _, msg := range errorMessages(resticErr.Stderr) {
+			if msg.isVerifyError() {
+				verifyErrors++
+			} else {
+				restoreErrors++
+			}
+		}
+		summary.VerifyErrors = verifyErrors
+		if summary.FilesErrored == 0 {
+			summary.FilesErrored = restoreErrors
+		}
+	}
+
+	if summary.VerifyErrors > 0 && summary.FilesErrored == 0 {
+		return &summary, fmt.Errorf("%w: %d files: %w", ErrRestoreVerify, summary.VerifyErrors, err)
+	}
+
+	if summary.FilesErrored > 0 {
+		if err != nil {
+			return &summary, fmt.Errorf("%w: %d files: %w", ErrRestorePartial, summary.FilesErrored, err)
+		}
+		return &summary, fmt.Errorf("%w: %d files", ErrRestorePartial, summary.FilesErrored)
 	}
 
-	var summary RestoreSummary
-	err = json.Unmarshal(res, &summary)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 
 	return &summary, nil
 }
 
 // Forget forgets a snapshot.
+// With forget.WithDryRun nothing is removed, the summaries list the snapshots which would be removed.
 // If a snapshot ID is given, some option will be ignored by restic.
 // E.g. --host, --tag and --path. See documentation: https://restic.readthedocs.io/en/stable/060_forget.html#remove-a-single-snapshot
 func (r *Repository) Forget(ctx context.Context, options ...forget.OptionFunc) ([]ForgetSummary, error) {
@@ -199,53 +674,286 @@ func (r *Repository) Forget(ctx context.Context, options ...forget.OptionFunc) (
 		return nil, errors.New("at least one option must be set")
 	}
 
-	args := []string{
-		"--json", // json output seems not supported yet, so there is no output with exit 0
-		"forget",
-	}
-
+	args := []string{"--json", "forget"}
 	args = append(args, forget.Args(options...)...)
-	out, err := r.command(ctx, "", args...)
+
+	out, err := r.writeCommand(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
 
+	// the summaries are printed as a single JSON array, followed by
+	// the text output of prune with forget.WithPrune
 	data, err := getSummary(out)
 	if err != nil {
 		return nil, err
 	}
 
-	var summary []ForgetSummary
-	err = json.Unmarshal(data, &summary)
-	if err != nil {
-		// as long --json is not supported on forget, we return nil, nil
-		return nil, nil
+	// restic prints nothing if single snapshots are forgotten by ID
+	summary := make([]ForgetSummary, 0)
+	if len(data) == 0 {
+		return summary, nil
+	}
+
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to decode forget output %q: %w", data, err)
 	}
 
 	return summary, nil
 }
 
-// Unlock remove locks other processes created on the repository
-func (r *Repository) Unlock(ctx context.Context) error {
-	// TODO: remove all as option
-	args := []string{"unlock", "--remove-all", "--json"}
+// UnlockResult holds the outcome of an unlock
+type UnlockResult struct {
+	RemovedLocks int
+	Duration     time.Duration
+}
 
-	_, err := r.command(ctx, "", args...)
+var removedLocksRegex = regexp.MustCompile(`successfully removed (\d+) locks`)
+
+// Unlock remove locks other processes created on the repository.
+// Only stale locks are removed unless unlock.WithRemoveAll is set.
+// It waits for the commands of this Repository writing to the repository, so
+// their locks are never removed.
+// The result is returned even if the command failed.
+func (r *Repository) Unlock(ctx context.Context, options ...unlock.OptionFunc) (*UnlockResult, error) {
+	args := []string{"unlock"}
+	args = append(args, unlock.Args(options...)...)
+
+	start := time.Now()
+	out, err := r.writeCommand(ctx, args...)
+	res := &UnlockResult{
+		Duration: time.Since(start),
+	}
 	if err != nil {
-		return err
+		return res, err
 	}
 
-	return nil
+	if m := removedLocksRegex.FindStringSubmatch(out); m != nil {
+		res.RemovedLocks, _ = strconv.Atoi(m[1])
+	}
+
+	return res, nil
 }
 
-// command wraps the restic command and injects repo and password as environment variables to the process
+// invocation holds the settings of a single restic run
+type invocation struct {
+	dir   string
+	umask *os.FileMode
+	// stdin is passed to the command
+	stdin io.Reader
+	// env is added to the environment of the repository
+	env []string
+	// stdout receives the output instead of returning it
+	stdout io.Writer
+	// onLine is called for every line of the output as it arrives,
+	// lines it returns true for are consumed and not returned
+	onLine func(line []byte) bool
+	// write serializes the command with the other commands writing to the repository
+	write bool
+}
+
+// command runs the restic command in dir and returns its output
 func (r *Repository) command(ctx context.Context, dir string, args ...string) (string, error) {
+	return r.run(ctx, invocation{dir: dir}, args...)
+}
+
+// readOnly returns the args of a read-only command, which doesn't lock the repository
+// unless WithLock is set
+func (r *Repository) readOnly(args ...string) []string {
+	if r.lock {
+		return args
+	}
+	return append([]string{"--no-lock"}, args...)
+}
+
+// writeCommand runs the restic command writing to the repository and returns its output
+func (r *Repository) writeCommand(ctx context.Context, args ...string) (string, error) {
+	return r.run(ctx, invocation{write: true}, args...)
+}
+
+// lockWrite waits until no other command writes to the repository.
+// It returns the error of ctx if ctx is done before.
+func (r *Repository) lockWrite(ctx context.Context) error {
+	select {
+	case r.writeSem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// unlockWrite allows the next command to write to the repository
+func (r *Repository) unlockWrite() {
+	<-r.writeSem
+}
+
+// SetCommandHook sets fn to be called before every restic command with its arguments.
+// Secrets are redacted from the arguments, the environment holding the password isn't passed.
+func (r *Repository) SetCommandHook(fn func(ctx context.Context, args []string)) {
+	r.hookMu.Lock()
+	defer r.hookMu.Unlock()
+	r.commandHook = fn
+}
+
+// SetResultHook sets fn to be called after every restic command with its arguments, output,
+// error and duration, e.g. to collect metrics. Secrets are redacted from the arguments and stderr.
+func (r *Repository) SetResultHook(fn func(ctx context.Context, args []string, stdout string, stderr string, err error, d time.Duration)) {
+	r.hookMu.Lock()
+	defer r.hookMu.Unlock()
+	r.resultHook = fn
+}
+
+// hooks returns the command and result hooks
+func (r *Repository) hooks() (func(context.Context, []string), func(context.Context, []string, string, string, error, time.Duration)) {
+	r.hookMu.RLock()
+	defer r.hookMu.RUnlock()
+	return r.commandHook, r.resultHook
+}
+
+// query runs the read-only restic command and retries it on retryable errors, see WithRetry
+func (r *Repository) query(ctx context.Context, args ...string) (string, error) {
+	return r.retry(ctx, invocation{}, args...)
+}
+
+// retry runs the restic command with the settings of inv and retries it on retryable errors
+func (r *Repository) retry(ctx context.Context, inv invocation, args ...string) (string, error) {
+	backoff := r.retryBackoff
+	for attempt := 1; ; attempt++ {
+		out, err := r.run(ctx, inv, args...)
+		if err == nil || attempt >= r.retryAttempts || !IsRetryable(err) {
+			return out, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return out, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// run runs the restic command with the settings of inv and returns its output.
+// If the command fails, the output so far is returned with a *ResticError.
+func (r *Repository) run(ctx context.Context, inv invocation, args ...string) (string, error) {
+
+	if err := r.prepare(ctx); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+
+	if inv.write {
+		if err := r.lockWrite(ctx); err != nil {
+			return "", err
+		}
+		defer r.unlockWrite()
+	}
+
+	p, err := r.startProcess(ctx, inv, args...)
+	if err != nil {
+		return p.stdOut.String(), err
+	}
+
+	return p.wait()
+}
+
+// process is a started restic command
+type process struct {
+	repo   *Repository
+	ctx    context.Context
+	args   []string
+	cmd    *exec.Cmd
+	stdOut *bytes.Buffer
+	stdErr *bytes.Buffer
+	lines  *lineWriter
+	start  time.Time
+
+	hookArgs   []string
+	resultHook func(ctx context.Context, args []string, stdout string, stderr string, err error, d time.Duration)
+}
+
+// startProcess starts the restic command with the settings of inv and calls the command hook.
+// If the command can't be started, the result hook is called with the error.
+func (r *Repository) startProcess(ctx context.Context, inv invocation, args ...string) (*process, error) {
+	p := &process{
+		repo:   r,
+		ctx:    ctx,
+		args:   args,
+		cmd:    r.newCmd(ctx, inv, args...),
+		stdOut: new(bytes.Buffer),
+		stdErr: new(bytes.Buffer),
+	}
+	p.cmd.Stdout = p.stdOut
+	p.cmd.Stderr = p.stdErr
+	p.cmd.Stdin = inv.stdin
 
-	envArgs := []string{
-		"RESTIC_PASSWORD=" + r.password,
-		"RESTIC_REPOSITORY=" + r.path,
+	if inv.stdout != nil {
+		p.cmd.Stdout = inv.stdout
 	}
 
+	if inv.onLine != nil {
+		p.lines = &lineWriter{w: p.cmd.Stdout, fn: inv.onLine}
+		p.cmd.Stdout = p.lines
+	}
+
+	commandHook, resultHook := r.hooks()
+	p.resultHook = resultHook
+	if commandHook != nil || resultHook != nil {
+		p.hookArgs = r.redactArgs(r.cmdArgs(args))
+	}
+	if commandHook != nil {
+		commandHook(ctx, p.hookArgs)
+	}
+
+	p.start = time.Now()
+	var err error
+	if inv.umask != nil {
+		err = startWithUmask(p.cmd, *inv.umask)
+	} else {
+		err = p.cmd.Start()
+	}
+	if err != nil {
+		_, err = p.finish(err)
+		return p, err
+	}
+
+	return p, nil
+}
+
+// wait waits for the started command and returns its output.
+// If the command fails, the output so far is returned with a *ResticError.
+func (p *process) wait() (string, error) {
+	err := p.cmd.Wait()
+	if err != nil {
+		err = p.repo.resticError(err, p.args, p.stdErr.String())
+	}
+
+	return p.finish(err)
+}
+
+// finish flushes the output, calls the result hook and returns the output
+func (p *process) finish(err error) (string, error) {
+	if p.lines != nil {
+		if ferr := p.lines.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+
+	if p.resultHook != nil {
+		p.resultHook(p.ctx, p.hookArgs, p.stdOut.String(), p.repo.redact(p.stdErr.String()), err, time.Since(p.start))
+	}
+
+	// the output is returned on failure as well, e.g. the summary of a partial backup
+	return p.stdOut.String(), err
+}
+
+// newCmd wraps the restic command and injects repo and password as environment variables to the process
+func (r *Repository) newCmd(ctx context.Context, inv invocation, args ...string) *exec.Cmd {
+
+	envArgs := r.repoEnv("RESTIC_")
+
 	home, err := os.UserHomeDir()
 	if err == nil {
 		envArgs = append(envArgs, "HOME="+home)
@@ -253,47 +961,208 @@ func (r *Repository) command(ctx context.Context, dir string, args ...string) (s
 
 	envArgs = append(envArgs, "PATH="+os.Getenv("PATH"))
 
-	// buffers for output
-	stdErr := new(bytes.Buffer)
-	stdOut := new(bytes.Buffer)
+	keys := make([]string, 0, len(r.env))
+	for k := range r.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		envArgs = append(envArgs, k+"="+r.env[k])
+	}
+
+	envArgs = append(envArgs, inv.env...)
 
-	cmd := exec.CommandContext(ctx, resticBin, args...)
+	name, args := r.withPriority(r.bin, r.cmdArgs(args))
+	cmd := exec.CommandContext(ctx, name, args...)
 
 	// set the execute dir
-	if dir != "" {
-		cmd.Dir = dir
+	if inv.dir != "" {
+		cmd.Dir = inv.dir
 	}
 
 	cmd.Env = envArgs
-	cmd.Stdout = stdOut
-	cmd.Stderr = stdErr
 
-	// run the command
-	if err := cmd.Run(); err != nil {
-		return "", parseStdErr(stdErr.String())
+	return cmd
+}
+
+// withTimeout returns ctx limited to the default timeout if ctx has no deadline, see WithDefaultTimeout
+func (r *Repository) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || r.defaultTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.defaultTimeout)
+}
+
+// cmdArgs returns the arguments of restic with the global args inserted before args
+func (r *Repository) cmdArgs(args []string) []string {
+	if len(r.globalArgs) == 0 {
+		return args
+	}
+	return append(append([]string{}, r.globalArgs...), args...)
+}
+
+// fromEnv returns the environment to use the repository as secondary repository,
+// e.g. as source of the chunker parameters or of a copy
+func (r *Repository) fromEnv() []string {
+	return r.repoEnv("RESTIC_FROM_")
+}
+
+// secondaryEnv returns the environment to use the repository as secondary repository of primary,
+// including the backend variables set with WithEnv which primary doesn't set as well
+func (r *Repository) secondaryEnv(primary *Repository) []string {
+	env := r.fromEnv()
+
+	keys := make([]string, 0, len(r.env))
+	for k := range r.env {
+		if _, ok := primary.env[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		env = append(env, k+"="+r.env[k])
+	}
+
+	return env
+}
+
+// repoEnv returns the repository location and password as environment variables with the prefix
+func (r *Repository) repoEnv(prefix string) []string {
+	env := []string{
+		prefix + "REPOSITORY=" + r.path,
 	}
 
-	return stdOut.String(), nil
+	r.credMu.RLock()
+	defer r.credMu.RUnlock()
+
+	switch {
+	case r.passwordFile != "":
+		env = append(env, prefix+"PASSWORD_FILE="+r.passwordFile)
+	case r.passwordCommand != "":
+		env = append(env, prefix+"PASSWORD_COMMAND="+r.passwordCommand)
+	default:
+		env = append(env, prefix+"PASSWORD="+r.password)
+	}
+
+	return env
 }
 
 var (
-	ErrRepoAlreadyExist error = errors.New("restic repo already exist, use restic.Connect")
-	ErrInvalidID        error = errors.New("invalid snapshot ID")
-	ErrRepoLocked       error = errors.New("repository is already locked")
+	ErrRepoAlreadyExist   error = errors.New("restic repo already exist, use restic.Connect")
+	ErrInvalidID          error = errors.New("invalid snapshot ID")
+	ErrRepoLocked         error = errors.New("repository is already locked")
+	ErrNoSpace            error = errors.New("no space left on backend")
+	ErrChecksumMismatch   error = errors.New("restic binary checksum mismatch")
+	ErrInvalidObjectType  error = errors.New("invalid object type")
+	ErrInvalidPassword    error = errors.New("wrong password or no key found")
+	ErrRepoNotFound       error = errors.New("repository not found")
+	ErrNetwork            error = errors.New("network error")
+	ErrInvalidRepoString  error = errors.New("invalid repository string")
+	ErrResticNotFound     error = errors.New("restic not found, it must be installed and exported in $PATH")
+	ErrResticVersion      error = errors.New("restic version not supported, minimum is " + minVersion)
+	ErrPasswordSource     error = errors.New("exactly one of password, password file or password command must be set")
+	ErrNothingToRecover   error = errors.New("no unreferenced trees to recover")
+	ErrExcludeFile        error = errors.New("exclude file missing or not readable")
+	ErrInvalidCompression error = errors.New("invalid compression level, must be auto, max or off")
+	ErrBackupPartial      error = errors.New("backup completed, but some files could not be read")
+	ErrPermissionDenied   error = errors.New("permission denied")
+	ErrRepoOpen           error = errors.New("unable to open repository")
+	ErrEmptyPolicy        error = errors.New("retention policy keeps no snapshots")
+	ErrRestorePartial     error = errors.New("restore completed, but some files could not be restored")
+	ErrRestoreVerify      error = errors.New("restored files don't match the snapshot")
+	ErrInvalidOverwrite   error = errors.New("invalid overwrite mode, must be always, if-changed, if-newer or never")
+	ErrHostScope          error = errors.New("host is outside the scope of the repository")
+	ErrInvalidSize        error = errors.New("invalid size")
+	ErrStdinOption        error = errors.New("option can't be used with a backup of stdin")
+	ErrInvalidMeta        error = errors.New("invalid meta data, the key must not contain '=' and neither key nor value ','")
+
+	// ErrRepoExists is an alias of ErrRepoAlreadyExist
+	ErrRepoExists error = ErrRepoAlreadyExist
 )
 
-// parseStdErr parses the stderr output from the restic command
+// parseStdErr parses the stderr output from the restic command.
+// The messages are matched case-insensitively, since their spelling differs between
+// restic versions and backends. It returns nil if the output is unknown.
 func parseStdErr(stdErr string) error {
 	switch {
-	case strings.Contains(stdErr, "failed: config file already exists"):
+	case containsAny(stdErr, "config file already exists"):
 		return ErrRepoAlreadyExist
-	case strings.Contains(stdErr, "returned error, retrying after"):
+	case containsAny(stdErr, invalidIDMessages...):
 		return ErrInvalidID
-	case strings.Contains(stdErr, "unable to create lock in backend: repository is already locked"):
-		return ErrRepoLocked
+	case containsAny(stdErr, "repository is already locked"):
+		return parseLockedError(stdErr)
+	case containsAny(stdErr, noSpaceMessages...):
+		return fmt.Errorf("%w: %s", ErrNoSpace, matchingLine(stdErr, noSpaceMessages...))
+	case containsAny(stdErr, "wrong password or no key found"):
+		return ErrInvalidPassword
+	case containsAny(stdErr, repoNotFoundMessages...):
+		return ErrRepoNotFound
+	case containsAny(stdErr, networkMessages...):
+		return fmt.Errorf("%w: %s", ErrNetwork, matchingLine(stdErr, networkMessages...))
+	case containsAny(stdErr, "permission denied"):
+		return fmt.Errorf("%w: %s", ErrPermissionDenied, matchingLine(stdErr, "permission denied"))
+	case containsAny(stdErr, "unable to open repository"):
+		return fmt.Errorf("%w: %s", ErrRepoOpen, matchingLine(stdErr, "unable to open repository"))
 	}
 
-	return errors.New(stdErr)
+	return nil
+}
+
+// invalidIDMessages are reported if a snapshot ID doesn't match any snapshot
+var invalidIDMessages = []string{
+	"no matching ID found",
+	"invalid snapshot ID",
+}
+
+// noSpaceMessages are reported by the backends when they run out of space
+var noSpaceMessages = []string{
+	"no space left on device",
+	"XMinioStorageFull",
+	"InsufficientStorage",
+	"Insufficient Storage",
+}
+
+// repoNotFoundMessages are reported if there is no repository at the location
+var repoNotFoundMessages = []string{
+	"Is there a repository at the following location?",
+	"The specified bucket does not exist",
+	"repository does not exist",
+	"unable to open config file",
+}
+
+// networkMessages are reported on transient connection problems to the backend
+var networkMessages = []string{
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"no such host",
+	"unexpected EOF",
+	"502 Bad Gateway",
+	"503 Service Unavailable",
+	"504 Gateway Timeout",
+}
+
+// containsAny reports whether s contains any of the substrs, ignoring case
+func containsAny(s string, substrs ...string) bool {
+	s = strings.ToLower(s)
+	for _, sub := range substrs {
+		if strings.Contains(s, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchingLine returns the first trimmed line of s containing any of the substrs
+func matchingLine(s string, substrs ...string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if containsAny(line, substrs...) {
+			return strings.TrimSpace(line)
+		}
+	}
+	return strings.TrimSpace(s)
 }
 
 // isPathExists checks if the path p exists
@@ -307,25 +1176,91 @@ func isPathExists(p string) bool {
 	return true
 }
 
-func isSnapshotID(id string) bool {
+// topmostMissingDir returns the topmost directory of p which doesn't exist yet
+func topmostMissingDir(p string) string {
+	p = filepath.Clean(p)
+	for {
+		parent := filepath.Dir(p)
+		if parent == p || isPathExists(parent) {
+			return p
+		}
+		p = parent
+	}
+}
+
+// IsSnapshotID reports whether id is a valid snapshot reference as accepted by Restore:
+// "latest" or a short or full snapshot ID, optionally followed by ":path".
+func IsSnapshotID(id string) bool {
 	return idRegex.MatchString(id)
 }
 
+// SnapshotRef references a snapshot and optionally a path inside of it, e.g. "latest:/home"
+type SnapshotRef struct {
+	ID   string
+	Path string
+}
+
+func (s SnapshotRef) String() string {
+	if s.Path == "" {
+		return s.ID
+	}
+	return s.ID + ":" + s.Path
+}
+
+// ParseSnapshotRef splits a snapshot reference of the form "id" or "id:path" and validates it.
+func ParseSnapshotRef(s string) (SnapshotRef, error) {
+	if !IsSnapshotID(s) {
+		return SnapshotRef{}, fmt.Errorf("%w: '%s'", ErrInvalidID, s)
+	}
+
+	id, path, found := strings.Cut(s, ":")
+	if found && path == "" {
+		return SnapshotRef{}, fmt.Errorf("empty path in snapshot reference '%s'", s)
+	}
+
+	return SnapshotRef{ID: id, Path: path}, nil
+}
+
+// getSummary returns the summary line of the JSON output: the line with the message type
+// "summary", or the JSON array forget emits. Output of restic versions without message
+// types falls back to the last JSON object.
 func getSummary(output string) ([]byte, error) {
 	reader := bufio.NewReader(strings.NewReader(output))
-	res := make([]byte, 0)
+
+	var res, fallback []byte
 	for {
-		line, _, err := reader.ReadLine()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
 			return nil, errors.New("failed to read output")
 		}
 
-		if strings.Contains(string(line), "summary") || strings.Contains(string(line), `"tags":`) {
-			res = line
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			switch line[0] {
+			case '[':
+				res = line
+			case '{':
+				var msg struct {
+					MessageType string `json:"message_type"`
+				}
+				if json.Unmarshal(line, &msg) == nil {
+					if msg.MessageType == "summary" {
+						res = line
+					} else if msg.MessageType == "" {
+						fallback = line
+					}
+				}
+			}
+		}
+
+		if err == io.EOF {
+			break
 		}
 	}
+
+	if res == nil {
+		res = fallback
+	}
+
 	return res, nil
 }
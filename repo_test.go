@@ -0,0 +1,289 @@
+package restic
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/backup"
+)
+
+func TestBackupArgs_symlink(t *testing.T) {
+	tmp := t.TempDir()
+	data := filepath.Join(tmp, "data")
+	if err := os.Mkdir(data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmp, "link")
+	if err := os.Symlink(data, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	r := Open(filepath.Join(t.TempDir(), "repo"), "secret")
+
+	tests := []struct {
+		name       string
+		opts       []backup.OptionFunc
+		wantDir    string
+		wantTarget string
+	}{
+		{
+			name:       "symlink is followed",
+			opts:       nil,
+			wantDir:    link,
+			wantTarget: ".",
+		},
+		{
+			name:       "symlink root",
+			opts:       []backup.OptionFunc{backup.WithSymlinkRoot()},
+			wantDir:    tmp,
+			wantTarget: "link",
+		},
+		{
+			name:       "symlink root with path",
+			opts:       []backup.OptionFunc{backup.WithSymlinkRoot(), backup.WithPath("sub")},
+			wantDir:    tmp,
+			wantTarget: filepath.Join("link", "sub"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, args, err := r.backupArgs(link, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dir != tt.wantDir {
+				t.Errorf("dir = %q, want %q", dir, tt.wantDir)
+			}
+			if target := args[len(args)-1]; target != tt.wantTarget {
+				t.Errorf("target = %q, want %q", target, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestBackupResult_malformedSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+	}{
+		{
+			name: "empty",
+			out:  "",
+		},
+		{
+			name: "garbage",
+			out:  "Fatal: something went wrong",
+		},
+		{
+			name: "truncated",
+			out:  `{"message_type":"summary","files_new":3,"files_chan`,
+		},
+		{
+			name: "wrong type",
+			out:  `{"message_type":"summary","files_new":"three","total_files":"three"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := backupResult(tt.out, nil, false)
+			if err == nil {
+				t.Fatalf("backupResult() returned no error, summary %+v", summary)
+			}
+			if summary != nil {
+				t.Errorf("backupResult() summary = %+v, want nil", summary)
+			}
+			// the raw output is part of the error for debugging
+			if tt.out != "" && !strings.Contains(err.Error(), strings.ReplaceAll(tt.out, `"`, `\"`)) {
+				t.Errorf("error %q doesn't contain the output %q", err, tt.out)
+			}
+
+			var restore RestoreSummary
+			if err := parseSummary(tt.out, &restore); err == nil {
+				t.Errorf("parseSummary() returned no error for %q", tt.out)
+			}
+		})
+	}
+}
+
+// fakeRestic is a restic stand-in which fails like restic if the repository is locked
+const fakeRestic = `#!/bin/sh
+if [ "$1" = "version" ]; then
+	echo "restic 0.16.4 compiled with go1.21.6 on linux/amd64"
+	exit 0
+fi
+# log the content of the exclude files restic would read
+prev=""
+for arg in "$@"; do
+	if [ "$prev" = "--exclude-file" ] && [ -n "$FAKE_RESTIC_LOG" ]; then
+		cat "$arg" >> "$FAKE_RESTIC_LOG"
+	fi
+	prev="$arg"
+done
+if ! mkdir "$FAKE_RESTIC_LOCK" 2>/dev/null; then
+	echo "unable to create lock in backend: repository is already locked by PID 1 on host by user" >&2
+	exit 1
+fi
+sleep 0.1
+rmdir "$FAKE_RESTIC_LOCK"
+echo '{"message_type":"summary","files_new":1,"snapshot_id":"0123456789abcdef"}'
+`
+
+// newFakeRepo returns a repository run by fakeRestic and a source directory to back up
+func newFakeRepo(t *testing.T, opts ...Option) (*Repository, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake restic is a shell script")
+	}
+
+	tmp := t.TempDir()
+	bin := filepath.Join(tmp, "restic")
+	if err := os.WriteFile(bin, []byte(fakeRestic), 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(tmp, "source")
+	if err := os.Mkdir(source, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts = append([]Option{
+		WithResticBinary(bin),
+		WithEnv(map[string]string{"FAKE_RESTIC_LOCK": filepath.Join(tmp, "lock")}),
+	}, opts...)
+
+	return Open(filepath.Join(tmp, "repo"), "secret", opts...), source
+}
+
+func TestBackup_concurrent(t *testing.T) {
+	r, source := newFakeRepo(t)
+
+	const backups = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, backups)
+	for i := 0; i < backups; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Backup(context.Background(), source)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent Backup() failed: %v", err)
+		}
+	}
+}
+
+func TestBackupHandle_abort(t *testing.T) {
+	r, source := newFakeRepo(t)
+
+	var mu sync.Mutex
+	results := 0
+	r.SetResultHook(func(ctx context.Context, args []string, stdout string, stderr string, err error, d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		results++
+	})
+
+	h, err := r.StartBackup(context.Background(), source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := h.Wait()
+	if !errors.Is(err, ErrBackupAborted) {
+		t.Errorf("Wait() error = %v, want ErrBackupAborted", err)
+	}
+	if summary != nil {
+		t.Errorf("Wait() summary = %+v, want nil", summary)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if results != 1 {
+		t.Errorf("result hook called %d times, want 1", results)
+	}
+}
+
+func TestBackup_excludeFileChanges(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "log")
+	r, source := newFakeRepo(t, WithEnv(map[string]string{"FAKE_RESTIC_LOG": log}))
+
+	excludes := filepath.Join(t.TempDir(), "excludes")
+	opts := []backup.OptionFunc{backup.WithExcludeFile(excludes)}
+
+	for _, content := range []string{"*.tmp\n", "*.log\n"} {
+		if err := os.WriteFile(excludes, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Backup(context.Background(), source, opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// every backup reads the content of the exclude file at that time
+	got, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*.tmp\n*.log\n"; string(got) != want {
+		t.Errorf("exclude files read by the backups = %q, want %q", got, want)
+	}
+}
+
+func TestValidateBackupOptions_excludeLargerThan(t *testing.T) {
+	tests := []struct {
+		name string
+		size string
+		want error
+	}{
+		{name: "size", size: "500M", want: nil},
+		{name: "empty", size: "", want: ErrInvalidSize},
+		{name: "whitespace", size: "  ", want: ErrInvalidSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBackupOptions(backup.WithExcludeLargerThan(tt.size))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("validateBackupOptions() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck_retry(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "lock")
+	r, _ := newFakeRepo(t,
+		WithEnv(map[string]string{"FAKE_RESTIC_LOCK": lock}),
+		WithRetry(5, 50*time.Millisecond),
+	)
+
+	// another process holds the lock for a while
+	if err := os.Mkdir(lock, 0755); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		os.Remove(lock)
+	}()
+
+	if _, err := r.Check(context.Background()); err != nil {
+		t.Errorf("Check() = %v, want success after retrying", err)
+	}
+}
@@ -1,11 +1,18 @@
 package restic
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 
+	"github.com/alexjoedt/go-restic-wrapper/selfupdate"
 	"github.com/hashicorp/go-version"
 )
 
@@ -14,39 +21,235 @@ const (
 	minVersion string = "0.16.0"
 )
 
-func init() {
-	_, err := exec.LookPath(resticBin)
+var updatedRgx = regexp.MustCompile(`successfully updated restic to version (\S+)`)
+
+// EnsureRestic checks restic is installed in $PATH with at least the minimum supported version.
+// It returns ErrResticNotFound or ErrResticVersion otherwise.
+// See https://restic.readthedocs.io/en/latest/020_installation.html
+func EnsureRestic(ctx context.Context) error {
+	_, err := ensureRestic(ctx, resticBin)
+	return err
+}
+
+// Version returns the version of the restic binary in $PATH, e.g. "0.16.4"
+func Version(ctx context.Context) (string, error) {
+	v, err := ParsedVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return v.String(), nil
+}
+
+// ParsedVersion returns the version of the restic binary in $PATH for comparisons
+func ParsedVersion(ctx context.Context) (*version.Version, error) {
+	path, err := exec.LookPath(resticBin)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResticNotFound, err)
+	}
+
+	out, err := resticVersion(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseVersion(out)
+}
+
+// ResticVersion returns the version of the restic binary used by the repository,
+// see WithResticBinary
+func (r *Repository) ResticVersion(ctx context.Context) (string, error) {
+	if err := r.prepare(ctx); err != nil {
+		return "", err
+	}
+
+	out, err := resticVersion(ctx, r.bin)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := parseVersion(out)
+	if err != nil {
+		return "", err
+	}
+
+	return v.String(), nil
+}
+
+// requireVersion returns ErrResticVersion if the restic binary of the repository
+// is older than minV, which is needed for feature
+func (r *Repository) requireVersion(ctx context.Context, minV string, feature string) error {
+	if err := r.prepare(ctx); err != nil {
+		return err
+	}
+
+	out, err := resticVersion(ctx, r.bin)
+	if err != nil {
+		return err
+	}
+
+	v, err := parseVersion(out)
 	if err != nil {
-		fmt.Printf("[FATAL] %v\n", err)
-		fmt.Printf("[FATAL] restic must be installed and exported in $PATH\n")
-		fmt.Printf("[FATAL] https://restic.readthedocs.io/en/latest/020_installation.html\n")
-		os.Exit(1)
+		return err
+	}
+
+	if v.LessThan(version.Must(version.NewVersion(minV))) {
+		return fmt.Errorf("%w: %s requires restic %s, found %s", ErrResticVersion, feature, minV, v)
 	}
 
-	cmd := exec.Command(resticBin, "version")
-	out, err := cmd.CombinedOutput()
+	return nil
+}
+
+// ensureRestic looks up the restic binary bin, checks its version and returns its path
+func ensureRestic(ctx context.Context, bin string) (string, error) {
+	path, err := exec.LookPath(bin)
 	if err != nil {
-		fmt.Printf("[FATAL] %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("%w: %v", ErrResticNotFound, err)
+	}
+
+	out, err := resticVersion(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkResticVersion(out); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// resticVersion returns the output of restic version
+func resticVersion(ctx context.Context, path string) (string, error) {
+	out, err := exec.CommandContext(ctx, path, "version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get restic version: %w", err)
+	}
+
+	return string(out), nil
+}
+
+// parseVersion returns the version of the output of restic version
+func parseVersion(out string) (*version.Version, error) {
+	fields := strings.Fields(out)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("%w: unexpected version output %q", ErrResticVersion, out)
 	}
 
-	fields := strings.Fields(string(out))
 	v, err := version.NewVersion(fields[1])
-	must(err)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResticVersion, err)
+	}
+
+	return v, nil
+}
 
-	minV, err := version.NewVersion(minVersion)
-	must(err)
+// SelfUpdate updates the restic binary in $PATH to the latest release and returns
+// the versions before and after the update. Both are the same if restic was up to date.
+// restic must be built with self-update support, like the official release binaries.
+func SelfUpdate(ctx context.Context, options ...selfupdate.OptionFunc) (string, string, error) {
+	path, err := exec.LookPath(resticBin)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrResticNotFound, err)
+	}
 
+	out, err := resticVersion(ctx, path)
+	if err != nil {
+		return "", "", err
+	}
+	oldVersion, err := parseVersion(out)
+	if err != nil {
+		return "", "", err
+	}
+
+	args := []string{"self-update"}
+	args = append(args, selfupdate.Args(options...)...)
+
+	stdErr := new(bytes.Buffer)
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stderr = stdErr
+	updateOut, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("restic self-update failed: %w: %s", err, strings.TrimSpace(stdErr.String()))
+	}
+
+	newVersion := oldVersion.String()
+	if m := updatedRgx.FindStringSubmatch(string(updateOut) + stdErr.String()); m != nil {
+		newVersion = m[1]
+	}
+
+	return oldVersion.String(), newVersion, nil
+}
+
+// checkResticVersion checks the output of restic version against the minimum version
+func checkResticVersion(out string) error {
+	v, err := parseVersion(out)
+	if err != nil {
+		return err
+	}
+
+	minV := version.Must(version.NewVersion(minVersion))
 	if v.LessThan(minV) {
-		fmt.Printf("[FATAL] restic must be minimum version %s\n", minVersion)
-		os.Exit(1)
+		return fmt.Errorf("%w: %s is older than %s", ErrResticVersion, v, minVersion)
+	}
+
+	return nil
+}
+
+// prepare resolves and verifies the restic binary before its first use.
+// Failures are not cached, so a later call checks again.
+func (r *Repository) prepare(ctx context.Context) error {
+	r.prepareMu.Lock()
+	defer r.prepareMu.Unlock()
+
+	if r.configErr != nil {
+		return r.configErr
+	}
+
+	if r.prepared {
+		return nil
+	}
+
+	path, err := exec.LookPath(r.bin)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrResticNotFound, err)
+	}
+
+	// verify the checksum before the binary runs for the first time
+	if r.checksum != "" {
+		if err := verifyChecksum(path, r.checksum); err != nil {
+			return err
+		}
 	}
 
+	if _, err := ensureRestic(ctx, path); err != nil {
+		return err
+	}
+
+	// run exactly the verified binary
+	r.bin = path
+	r.prepared = true
+
+	return nil
 }
 
-func must(err error) {
+// verifyChecksum compares the SHA-256 checksum of the file at path with the hex encoded want
+func verifyChecksum(path string, want string) error {
+	f, err := os.Open(path)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return err
 	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("%w: %s has %s, expected %s", ErrChecksumMismatch, path, got, want)
+	}
+
+	return nil
 }
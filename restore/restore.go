@@ -1,30 +1,74 @@
 package restore
 
+import (
+	"os"
+	"strings"
+
+	"github.com/alexjoedt/go-restic-wrapper/progress"
+)
+
+// Overwrite modes of restic restore, see WithOverwrite
+const (
+	OverwriteAlways    = "always"
+	OverwriteIfChanged = "if-changed"
+	OverwriteIfNewer   = "if-newer"
+	OverwriteNever     = "never"
+)
+
+// ValidOverwrite reports whether mode is an overwrite mode supported by restic
+func ValidOverwrite(mode string) bool {
+	switch mode {
+	case OverwriteAlways, OverwriteIfChanged, OverwriteIfNewer, OverwriteNever:
+		return true
+	}
+	return false
+}
+
 type OptionFunc func(opts *options)
 
 type options struct {
-	hosts   []string
-	paths   []string
-	tags    []string
-	exclude []string
-	include []string
+	hosts    []string
+	paths    []string
+	tags     []string
+	exclude  []string
+	include  []string
+	iexclude []string
+	iinclude []string
+
+	overwrite       string
+	verify          bool
+	delete          bool
+	cleanupOnCancel bool
+	umask           *os.FileMode
+	progress        func(progress.Status)
 }
 
 func Args(opts ...OptionFunc) []string {
-	var options options
-	for _, opt := range opts {
-		opt(&options)
-	}
+	return parse(opts...).args()
+}
 
-	return options.args()
+// CleanupOnCancel reports whether WithCleanupOnCancel is set in opts.
+func CleanupOnCancel(opts ...OptionFunc) bool {
+	return parse(opts...).cleanupOnCancel
 }
 
+// WithTags emits a separate --tag flag per tag, which selects snapshots having any of the tags.
 func WithTags(tags ...string) OptionFunc {
 	return func(opts *options) {
 		opts.tags = append(opts.tags, tags...)
 	}
 }
 
+// WithTagGroup emits the tags as a single comma separated --tag flag,
+// which selects snapshots having all of the tags.
+func WithTagGroup(tags ...string) OptionFunc {
+	return func(opts *options) {
+		if len(tags) > 0 {
+			opts.tags = append(opts.tags, strings.Join(tags, ","))
+		}
+	}
+}
+
 func WithIncludes(includes ...string) OptionFunc {
 	return func(opts *options) {
 		opts.include = append(opts.include, includes...)
@@ -37,6 +81,25 @@ func WithExcludes(excludes ...string) OptionFunc {
 	}
 }
 
+// WithIIncludes is like WithIncludes but matches the patterns case-insensitively
+func WithIIncludes(includes ...string) OptionFunc {
+	return func(opts *options) {
+		opts.iinclude = append(opts.iinclude, includes...)
+	}
+}
+
+// WithIExcludes is like WithExcludes but matches the patterns case-insensitively
+func WithIExcludes(excludes ...string) OptionFunc {
+	return func(opts *options) {
+		opts.iexclude = append(opts.iexclude, excludes...)
+	}
+}
+
+// Hosts returns the hosts set by WithHosts.
+func Hosts(opts ...OptionFunc) []string {
+	return parse(opts...).hosts
+}
+
 func WithHosts(hosts ...string) OptionFunc {
 	return func(opts *options) {
 		opts.hosts = append(opts.hosts, hosts...)
@@ -49,6 +112,90 @@ func WithPaths(paths ...string) OptionFunc {
 	}
 }
 
+// Umask returns the umask set by the options and whether it is set.
+func Umask(opts ...OptionFunc) (os.FileMode, bool) {
+	options := parse(opts...)
+	if options.umask == nil {
+		return 0, false
+	}
+	return *options.umask, true
+}
+
+// WithUmask runs restic with the given umask, e.g. 0077 to restore files only
+// accessible by the owner. The umask is set by sh for the restic process only,
+// the umask of the calling process is not changed.
+// The umask is only supported on Unix and ignored elsewhere.
+func WithUmask(mask os.FileMode) OptionFunc {
+	return func(opts *options) {
+		opts.umask = &mask
+	}
+}
+
+// Progress returns the progress callback set by WithProgress, nil if unset.
+func Progress(opts ...OptionFunc) func(progress.Status) {
+	return parse(opts...).progress
+}
+
+// WithProgress calls fn for every progress status restic emits while the restore is running.
+// fn is called sequentially from a separate goroutine and not anymore once the context is done.
+func WithProgress(fn func(progress.Status)) OptionFunc {
+	return func(opts *options) {
+		opts.progress = fn
+	}
+}
+
+// Overwrite returns the overwrite mode set by WithOverwrite, empty if unset.
+func Overwrite(opts ...OptionFunc) string {
+	return parse(opts...).overwrite
+}
+
+// WithOverwrite sets how existing files in the target are handled,
+// see OverwriteAlways, OverwriteIfChanged, OverwriteIfNewer and OverwriteNever.
+// It requires restic 0.17.0 or newer.
+func WithOverwrite(mode string) OptionFunc {
+	return func(opts *options) {
+		opts.overwrite = mode
+	}
+}
+
+// WithVerify reads the restored files again and verifies their content against the snapshot.
+// Failures are counted in RestoreSummary.VerifyErrors.
+func WithVerify() OptionFunc {
+	return func(opts *options) {
+		opts.verify = true
+	}
+}
+
+// Delete reports whether WithDelete is set in opts.
+func Delete(opts ...OptionFunc) bool {
+	return parse(opts...).delete
+}
+
+// WithDelete deletes files in the target which are not in the snapshot, so the target
+// becomes an exact copy of the snapshot. Use with care, the deleted files are lost.
+// Deleted files are counted in RestoreSummary.FilesDeleted. It requires restic 0.17.0 or newer.
+func WithDelete() OptionFunc {
+	return func(opts *options) {
+		opts.delete = true
+	}
+}
+
+// WithCleanupOnCancel removes the target directory if the restore is cancelled or fails.
+// Only a target created by the restore is removed, never a pre-existing directory.
+func WithCleanupOnCancel() OptionFunc {
+	return func(opts *options) {
+		opts.cleanupOnCancel = true
+	}
+}
+
+func parse(opts ...OptionFunc) options {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
 func (opts options) args() []string {
 	args := make([]string, 0)
 
@@ -72,5 +219,25 @@ func (opts options) args() []string {
 		args = append(args, "--include", include)
 	}
 
+	for _, exclude := range opts.iexclude {
+		args = append(args, "--iexclude", exclude)
+	}
+
+	for _, include := range opts.iinclude {
+		args = append(args, "--iinclude", include)
+	}
+
+	if opts.overwrite != "" {
+		args = append(args, "--overwrite", opts.overwrite)
+	}
+
+	if opts.verify {
+		args = append(args, "--verify")
+	}
+
+	if opts.delete {
+		args = append(args, "--delete")
+	}
+
 	return args
 }
@@ -0,0 +1,74 @@
+package restic
+
+import (
+	"context"
+	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/forget"
+)
+
+// RetentionPolicy describes which snapshots to keep, all other snapshots are forgotten.
+// Zero values are not applied. A snapshot is kept if any of the rules keeps it.
+type RetentionPolicy struct {
+	KeepLast    uint
+	KeepHourly  uint
+	KeepDaily   uint
+	KeepWeekly  uint
+	KeepMonthly uint
+	KeepYearly  uint
+	// KeepWithin keeps all snapshots taken within the duration before the latest snapshot
+	KeepWithin time.Duration
+	// KeepTag keeps all snapshots having all of the tags
+	KeepTag []string
+	// Prune removes the data of the forgotten snapshots
+	Prune bool
+}
+
+// options returns the forget options of the policy
+func (p RetentionPolicy) options() []forget.OptionFunc {
+	opts := []forget.OptionFunc{
+		forget.WithKeepLast(p.KeepLast),
+		forget.WithKeepHourly(p.KeepHourly),
+		forget.WithKeepDaily(p.KeepDaily),
+		forget.WithKeepWeekly(p.KeepWeekly),
+		forget.WithKeepMonthly(p.KeepMonthly),
+		forget.WithKeepYearly(p.KeepYearly),
+		forget.WithKeepWithin(p.KeepWithin),
+		forget.WithKeepTags(p.KeepTag...),
+	}
+
+	if p.Prune {
+		opts = append(opts, forget.WithPrune())
+	}
+
+	return opts
+}
+
+// empty reports whether the policy keeps no snapshots
+func (p RetentionPolicy) empty() bool {
+	return p.KeepLast == 0 && p.KeepHourly == 0 && p.KeepDaily == 0 && p.KeepWeekly == 0 &&
+		p.KeepMonthly == 0 && p.KeepYearly == 0 && p.KeepWithin <= 0 && len(p.KeepTag) == 0
+}
+
+// ApplyRetentionPolicy forgets all snapshots not kept by the policy.
+// The options select the snapshots the policy is applied to, e.g. forget.WithHosts.
+// It returns ErrEmptyPolicy if the policy keeps no snapshots.
+func (r *Repository) ApplyRetentionPolicy(ctx context.Context, policy RetentionPolicy, options ...forget.OptionFunc) ([]ForgetSummary, error) {
+	if policy.empty() {
+		return nil, ErrEmptyPolicy
+	}
+
+	return r.Forget(ctx, append(policy.options(), options...)...)
+}
+
+// ApplyRetentionPolicy forgets the snapshots of the scoped host not kept by the policy,
+// see Repository.ApplyRetentionPolicy. With Prune the data of all hosts is pruned,
+// but only the snapshots of the scoped host are forgotten.
+// It returns ErrHostScope if the options select another host.
+func (s *ScopedRepository) ApplyRetentionPolicy(ctx context.Context, policy RetentionPolicy, options ...forget.OptionFunc) ([]ForgetSummary, error) {
+	if policy.empty() {
+		return nil, ErrEmptyPolicy
+	}
+
+	return s.Forget(ctx, append(policy.options(), options...)...)
+}
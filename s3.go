@@ -0,0 +1,57 @@
+package restic
+
+import (
+	"context"
+	"strings"
+)
+
+// S3Credentials holds the credentials and connection settings of an S3 compatible backend
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only needed for temporary credentials
+	SessionToken string
+	// Region defaults to the region of the bucket
+	Region string
+	// Endpoint of S3 compatible storage like MinIO, e.g. "https://minio.local:9000".
+	// Defaults to AWS S3.
+	Endpoint string
+}
+
+// env returns the environment variables restic reads the credentials from
+func (c S3Credentials) env() map[string]string {
+	env := map[string]string{
+		"AWS_ACCESS_KEY_ID":     c.AccessKeyID,
+		"AWS_SECRET_ACCESS_KEY": c.SecretAccessKey,
+	}
+
+	if c.SessionToken != "" {
+		env["AWS_SESSION_TOKEN"] = c.SessionToken
+	}
+
+	if c.Region != "" {
+		env["AWS_DEFAULT_REGION"] = c.Region
+	}
+
+	return env
+}
+
+// S3Repo returns the repository string of the bucket and prefix
+func S3Repo(bucket string, prefix string, endpoint string) string {
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+
+	repo := "s3:" + strings.TrimSuffix(endpoint, "/") + "/" + bucket
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		repo += "/" + prefix
+	}
+
+	return repo
+}
+
+// OpenS3 connects to an existing repository stored in an S3 bucket under the prefix.
+func OpenS3(ctx context.Context, bucket string, prefix string, creds S3Credentials, password string, opts ...Option) (*Repository, error) {
+	opts = append([]Option{WithEnv(creds.env())}, opts...)
+	return Connect(ctx, S3Repo(bucket, prefix, creds.Endpoint), password, opts...)
+}
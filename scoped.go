@@ -0,0 +1,116 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alexjoedt/go-restic-wrapper/filter"
+	"github.com/alexjoedt/go-restic-wrapper/forget"
+	"github.com/alexjoedt/go-restic-wrapper/restore"
+)
+
+// ScopedRepository is a view on a Repository which applies a host to every operation.
+// It only exposes operations which can be restricted to the host.
+type ScopedRepository struct {
+	repo *Repository
+	host string
+}
+
+// ForHost returns a view on the repository whose Snapshots, Forget and Restore
+// only operate on snapshots of the given host.
+func (r *Repository) ForHost(host string) *ScopedRepository {
+	return &ScopedRepository{
+		repo: r,
+		host: host,
+	}
+}
+
+// Host returns the host the repository is scoped to
+func (s *ScopedRepository) Host() string {
+	return s.host
+}
+
+// Snapshots returns the snapshots of the scoped host.
+// It returns ErrHostScope if the filters select another host.
+func (s *ScopedRepository) Snapshots(ctx context.Context, filters ...filter.OptionFunc) ([]Snapshot, error) {
+	if err := s.checkHosts(filter.Hosts(filters...)); err != nil {
+		return nil, err
+	}
+
+	filters = append([]filter.OptionFunc{filter.WithHosts(s.host)}, filters...)
+	return s.repo.Snapshots(ctx, filters...)
+}
+
+// SnapshotGroups returns the snapshots of the scoped host grouped as requested by filter.WithGroupBy.
+// It returns ErrHostScope if the filters select another host.
+func (s *ScopedRepository) SnapshotGroups(ctx context.Context, filters ...filter.OptionFunc) ([]SnapshotGroup, error) {
+	if err := s.checkHosts(filter.Hosts(filters...)); err != nil {
+		return nil, err
+	}
+
+	filters = append([]filter.OptionFunc{filter.WithHosts(s.host)}, filters...)
+	return s.repo.SnapshotGroups(ctx, filters...)
+}
+
+// Forget forgets snapshots of the scoped host.
+// It returns ErrHostScope if the options select another host or a snapshot of another host.
+func (s *ScopedRepository) Forget(ctx context.Context, options ...forget.OptionFunc) ([]ForgetSummary, error) {
+	if err := s.checkHosts(forget.Hosts(options...)); err != nil {
+		return nil, err
+	}
+
+	// restic ignores --host if a snapshot ID is given
+	if id := forget.SnapshotID(options...); id != "" {
+		if err := s.checkSnapshot(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	options = append([]forget.OptionFunc{forget.WithHosts(s.host)}, options...)
+	return s.repo.Forget(ctx, options...)
+}
+
+// Restore restores a snapshot of the scoped host.
+// The host is used by restic to resolve "latest", other snapshot IDs are checked to belong to the host.
+// It returns ErrHostScope if the options select another host or the snapshot is of another host.
+func (s *ScopedRepository) Restore(ctx context.Context, snapshotID string, target string, options ...restore.OptionFunc) (*RestoreSummary, error) {
+	if err := s.checkHosts(restore.Hosts(options...)); err != nil {
+		return nil, err
+	}
+
+	// restic ignores --host if a snapshot ID is given
+	id, _, _ := strings.Cut(snapshotID, ":")
+	if id != "" && id != "latest" {
+		if err := s.checkSnapshot(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	options = append([]restore.OptionFunc{restore.WithHosts(s.host)}, options...)
+	return s.repo.Restore(ctx, snapshotID, target, options...)
+}
+
+// checkHosts returns ErrHostScope if any of the hosts is not the scoped host
+func (s *ScopedRepository) checkHosts(hosts []string) error {
+	for _, h := range hosts {
+		if h != s.host {
+			return fmt.Errorf("%w: '%s' is not '%s'", ErrHostScope, h, s.host)
+		}
+	}
+	return nil
+}
+
+// checkSnapshot returns ErrHostScope if the snapshot with the given ID is not of the scoped host
+func (s *ScopedRepository) checkSnapshot(ctx context.Context, id string) error {
+	sn, err := s.repo.SnapshotById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if sn.Hostname != s.host {
+		return fmt.Errorf("%w: snapshot %s is of host '%s', not '%s'", ErrHostScope, id, sn.Hostname, s.host)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package selfupdate
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	output string
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithOutput writes the new binary to path instead of replacing the running binary
+func WithOutput(path string) OptionFunc {
+	return func(opts *options) {
+		opts.output = path
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.output != "" {
+		args = append(args, "--output", opts.output)
+	}
+
+	return args
+}
@@ -0,0 +1,119 @@
+package restic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RepositorySet runs an operation on multiple repositories concurrently
+type RepositorySet struct {
+	repos          []*Repository
+	maxConcurrency int
+}
+
+// SetOption configures a RepositorySet
+type SetOption func(s *RepositorySet)
+
+// WithMaxConcurrency limits the number of restic processes running at once, defaults to unlimited
+func WithMaxConcurrency(n int) SetOption {
+	return func(s *RepositorySet) {
+		s.maxConcurrency = n
+	}
+}
+
+// NewRepositorySet creates a set of the given repositories
+func NewRepositorySet(repos []*Repository, opts ...SetOption) *RepositorySet {
+	s := &RepositorySet{
+		repos: repos,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Repositories returns the repositories of the set
+func (s *RepositorySet) Repositories() []*Repository {
+	return s.repos
+}
+
+// Do runs fn on every repository of the set and waits for all to finish.
+// A failure on one repository doesn't stop the others, if any failed a *SetError is returned.
+func (s *RepositorySet) Do(ctx context.Context, fn func(ctx context.Context, r *Repository) error) error {
+	limit := s.maxConcurrency
+	if limit <= 0 {
+		limit = len(s.repos)
+	}
+
+	sem := make(chan struct{}, limit)
+	errs := make([]error, len(s.repos))
+
+	var wg sync.WaitGroup
+	for i, repo := range s.repos {
+		wg.Add(1)
+		go func(i int, repo *Repository) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			errs[i] = fn(ctx, repo)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return &SetError{repos: s.repos, Errors: errs}
+		}
+	}
+
+	return nil
+}
+
+// SetError is returned by RepositorySet.Do if the operation failed on any repository
+type SetError struct {
+	// Errors holds the error per repository in the order of the set, nil on success
+	Errors []error
+	repos  []*Repository
+}
+
+func (e *SetError) Error() string {
+	msgs := make([]string, 0)
+	for i, err := range e.Errors {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", e.repos[i].Path(), err))
+		}
+	}
+	return fmt.Sprintf("failed on %d of %d repositories: %s", len(msgs), len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed repositories
+func (e *SetError) Unwrap() []error {
+	errs := make([]error, 0)
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Err returns the error of the repository r, nil if it succeeded or is not part of the set
+func (e *SetError) Err(r *Repository) error {
+	for i, repo := range e.repos {
+		if repo == r {
+			return e.Errors[i]
+		}
+	}
+	return nil
+}
@@ -32,7 +32,11 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/alexjoedt/go-restic-wrapper/filter"
 )
 
 // Snapshot is the state of a resource at one point in time.
@@ -54,6 +58,71 @@ type Snapshot struct {
 	ProgramVersion string `json:"program_version,omitempty"`
 }
 
+// Meta returns the metadata stored as "key=value" tags, see backup.WithMeta.
+// Tags which are not in the key=value form are ignored.
+func (s Snapshot) Meta() map[string]string {
+	meta := make(map[string]string)
+	for _, t := range s.Tags {
+		key, value, ok := strings.Cut(t, "=")
+		if !ok || key == "" {
+			continue
+		}
+		meta[key] = value
+	}
+	return meta
+}
+
+// Age returns the time since the snapshot was taken
+func (s Snapshot) Age() time.Duration {
+	return time.Since(s.Time)
+}
+
+// Before reports whether s was taken before other.
+// Snapshots taken at the same time are ordered by their ID, so the order is total.
+func (s Snapshot) Before(other Snapshot) bool {
+	if !s.Time.Equal(other.Time) {
+		return s.Time.Before(other.Time)
+	}
+
+	return s.idString() < other.idString()
+}
+
+// idString returns the ID of s, empty if unset.
+func (s Snapshot) idString() string {
+	if s.ID == nil {
+		return ""
+	}
+	return s.ID.String()
+}
+
+// SortSnapshotsByTime sorts the snapshots from oldest to newest, see Snapshot.Before.
+func SortSnapshotsByTime(snapshots []Snapshot) {
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Before(snapshots[j])
+	})
+}
+
+// Matches reports whether the snapshot satisfies the filters.
+// It allows to reuse the filters of Repository.Snapshots on already fetched snapshots.
+func (s Snapshot) Matches(opts ...filter.OptionFunc) bool {
+	return filter.Match(s.filterSnapshot(), opts...)
+}
+
+// filterSnapshot returns the attributes of s used by the filters.
+func (s Snapshot) filterSnapshot() filter.Snapshot {
+	fs := filter.Snapshot{
+		Hostname: s.Hostname,
+		Paths:    s.Paths,
+		Tags:     s.Tags,
+		Time:     s.Time,
+	}
+	if s.Original != nil {
+		fs.Original = s.Original.String()
+	}
+
+	return fs
+}
+
 // idSize contains the size of an ID, in bytes.
 const idSize = sha256.Size
 
@@ -0,0 +1,101 @@
+package restic
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testID(t *testing.T, c string) *ID {
+	t.Helper()
+	id, err := ParseID(strings.Repeat(c, 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &id
+}
+
+func TestSnapshot_Before(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a, b := testID(t, "a"), testID(t, "b")
+
+	tests := []struct {
+		name  string
+		s     Snapshot
+		other Snapshot
+		want  bool
+	}{
+		{
+			name:  "older",
+			s:     Snapshot{ID: b, Time: t0},
+			other: Snapshot{ID: a, Time: t0.Add(time.Second)},
+			want:  true,
+		},
+		{
+			name:  "newer",
+			s:     Snapshot{ID: a, Time: t0.Add(time.Second)},
+			other: Snapshot{ID: b, Time: t0},
+			want:  false,
+		},
+		{
+			name:  "same time, smaller ID",
+			s:     Snapshot{ID: a, Time: t0},
+			other: Snapshot{ID: b, Time: t0},
+			want:  true,
+		},
+		{
+			name:  "same time, larger ID",
+			s:     Snapshot{ID: b, Time: t0},
+			other: Snapshot{ID: a, Time: t0},
+			want:  false,
+		},
+		{
+			name:  "same instant in other zone",
+			s:     Snapshot{ID: b, Time: t0.In(time.FixedZone("CEST", 2*60*60))},
+			other: Snapshot{ID: a, Time: t0},
+			want:  false,
+		},
+		{
+			name:  "same time, nil ID first",
+			s:     Snapshot{Time: t0},
+			other: Snapshot{ID: a, Time: t0},
+			want:  true,
+		},
+		{
+			name:  "same time, both IDs nil",
+			s:     Snapshot{Time: t0},
+			other: Snapshot{Time: t0},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Before(tt.other); got != tt.want {
+				t.Errorf("Before() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortSnapshotsByTime(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a, b, c := testID(t, "a"), testID(t, "b"), testID(t, "c")
+
+	snapshots := []Snapshot{
+		{ShortID: "c-later", ID: c, Time: t0.Add(time.Hour)},
+		{ShortID: "b", ID: b, Time: t0},
+		{ShortID: "nil", Time: t0},
+		{ShortID: "a", ID: a, Time: t0},
+		{ShortID: "c-earlier", ID: c, Time: t0.Add(-time.Hour)},
+	}
+
+	SortSnapshotsByTime(snapshots)
+
+	want := []string{"c-earlier", "nil", "a", "b", "c-later"}
+	for i, s := range snapshots {
+		if s.ShortID != want[i] {
+			t.Fatalf("snapshot %d = %q, want order %q", i, s.ShortID, want)
+		}
+	}
+}
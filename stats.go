@@ -0,0 +1,49 @@
+package restic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/alexjoedt/go-restic-wrapper/stats"
+)
+
+// Stats holds the statistics of the repository or of the selected snapshots
+type Stats struct {
+	TotalSize      uint64 `json:"total_size"`
+	TotalFileCount uint64 `json:"total_file_count"`
+	TotalBlobCount uint64 `json:"total_blob_count"`
+	SnapshotsCount int    `json:"snapshots_count"`
+
+	// only set in raw-data mode
+	TotalUncompressedSize uint64  `json:"total_uncompressed_size,omitempty"`
+	CompressionRatio      float64 `json:"compression_ratio,omitempty"`
+}
+
+// Stats returns the statistics of the snapshots with the given IDs, of all snapshots if none are given.
+// Fetches the statistics in read only mode (--no-lock)
+func (r *Repository) Stats(ctx context.Context, snapshotIDs []string, options ...stats.OptionFunc) (*Stats, error) {
+
+	for _, id := range snapshotIDs {
+		if !IsSnapshotID(id) {
+			return nil, fmt.Errorf("%w: '%s'", ErrInvalidID, id)
+		}
+	}
+
+	args := r.readOnly("stats", "--json")
+	args = append(args, stats.Args(options...)...)
+	args = append(args, snapshotIDs...)
+
+	out, err := r.query(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var st Stats
+	err = json.Unmarshal([]byte(out), &st)
+	if err != nil {
+		return nil, err
+	}
+
+	return &st, nil
+}
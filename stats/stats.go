@@ -0,0 +1,80 @@
+package stats
+
+type OptionFunc func(opts *options)
+
+// Mode is the counting mode of restic stats
+type Mode string
+
+const (
+	// ModeRestoreSize counts the size of the files as they would be restored
+	ModeRestoreSize Mode = "restore-size"
+	// ModeFilesByContents counts the size of unique files by their contents
+	ModeFilesByContents Mode = "files-by-contents"
+	// ModeBlobsPerFile counts the size of the unique blobs referenced by the files
+	ModeBlobsPerFile Mode = "blobs-per-file"
+	// ModeRawData counts the size of the blobs stored in the repository
+	ModeRawData Mode = "raw-data"
+)
+
+type options struct {
+	mode  Mode
+	hosts []string
+	paths []string
+	tags  []string
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithMode sets the counting mode, restic defaults to ModeRestoreSize
+func WithMode(mode Mode) OptionFunc {
+	return func(opts *options) {
+		opts.mode = mode
+	}
+}
+
+func WithTags(tags ...string) OptionFunc {
+	return func(opts *options) {
+		opts.tags = append(opts.tags, tags...)
+	}
+}
+
+func WithHosts(hosts ...string) OptionFunc {
+	return func(opts *options) {
+		opts.hosts = append(opts.hosts, hosts...)
+	}
+}
+
+func WithPaths(paths ...string) OptionFunc {
+	return func(opts *options) {
+		opts.paths = append(opts.paths, paths...)
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.mode != "" {
+		args = append(args, "--mode", string(opts.mode))
+	}
+
+	for _, h := range opts.hosts {
+		args = append(args, "--host", h)
+	}
+
+	for _, p := range opts.paths {
+		args = append(args, "--path", p)
+	}
+
+	for _, t := range opts.tags {
+		args = append(args, "--tag", t)
+	}
+
+	return args
+}
@@ -0,0 +1,69 @@
+package restic
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/alexjoedt/go-restic-wrapper/progress"
+)
+
+// lineWriter passes every complete line written to fn. Lines which are not
+// consumed by fn are written to w.
+type lineWriter struct {
+	w   io.Writer
+	fn  func(line []byte) bool
+	buf []byte
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.buf = append(lw.buf, p...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := lw.buf[:i+1]
+		if !lw.fn(bytes.TrimSpace(line)) {
+			if _, err := lw.w.Write(line); err != nil {
+				return 0, err
+			}
+		}
+		lw.buf = lw.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes an incomplete last line
+func (lw *lineWriter) Flush() error {
+	if len(lw.buf) == 0 {
+		return nil
+	}
+
+	_, err := lw.w.Write(lw.buf)
+	lw.buf = nil
+	return err
+}
+
+// progressLines returns a line handler which consumes the status lines
+// and passes them with smoothed ETA and throughput to fn.
+// fn is not called anymore once ctx is done.
+func progressLines(ctx context.Context, fn func(progress.Status)) func(line []byte) bool {
+	tracker := &progress.Tracker{}
+	return func(line []byte) bool {
+		s, ok := progress.Parse(line)
+		if !ok {
+			return false
+		}
+
+		if ctx.Err() != nil {
+			return true
+		}
+
+		tracker.Update(&s)
+		fn(s)
+		return true
+	}
+}
@@ -0,0 +1,31 @@
+package restic
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/alexjoedt/go-restic-wrapper/tag"
+)
+
+var tagModifiedRgx = regexp.MustCompile(`(?i)modified (?:tags on )?(\d+) snapshots?`)
+
+// Tag adds, removes or sets the tags of the selected snapshots
+// and returns the number of modified snapshots.
+func (r *Repository) Tag(ctx context.Context, options ...tag.OptionFunc) (int, error) {
+	args := []string{"tag"}
+	args = append(args, tag.Args(options...)...)
+
+	out, err := r.writeCommand(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	m := tagModifiedRgx.FindStringSubmatch(out)
+	if m == nil {
+		// restic reports "no snapshots were modified"
+		return 0, nil
+	}
+
+	return strconv.Atoi(m[1])
+}
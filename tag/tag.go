@@ -0,0 +1,104 @@
+package tag
+
+import "strings"
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	add    []string
+	remove []string
+	set    []string
+	ids    []string
+	hosts  []string
+	paths  []string
+	tags   []string
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithAdd adds the tags to the snapshots
+func WithAdd(tags ...string) OptionFunc {
+	return func(opts *options) {
+		opts.add = append(opts.add, tags...)
+	}
+}
+
+// WithRemove removes the tags from the snapshots
+func WithRemove(tags ...string) OptionFunc {
+	return func(opts *options) {
+		opts.remove = append(opts.remove, tags...)
+	}
+}
+
+// WithSet replaces the tags of the snapshots.
+// It can't be combined with WithAdd or WithRemove.
+func WithSet(tags ...string) OptionFunc {
+	return func(opts *options) {
+		opts.set = append(opts.set, tags...)
+	}
+}
+
+// WithSnapshotIDs modifies only the given snapshots
+func WithSnapshotIDs(ids ...string) OptionFunc {
+	return func(opts *options) {
+		opts.ids = append(opts.ids, ids...)
+	}
+}
+
+func WithHosts(hosts ...string) OptionFunc {
+	return func(opts *options) {
+		opts.hosts = append(opts.hosts, hosts...)
+	}
+}
+
+func WithPaths(paths ...string) OptionFunc {
+	return func(opts *options) {
+		opts.paths = append(opts.paths, paths...)
+	}
+}
+
+// WithTags selects the snapshots having any of the tags
+func WithTags(tags ...string) OptionFunc {
+	return func(opts *options) {
+		opts.tags = append(opts.tags, tags...)
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if len(opts.add) > 0 {
+		args = append(args, "--add", strings.Join(opts.add, ","))
+	}
+
+	if len(opts.remove) > 0 {
+		args = append(args, "--remove", strings.Join(opts.remove, ","))
+	}
+
+	if len(opts.set) > 0 {
+		args = append(args, "--set", strings.Join(opts.set, ","))
+	}
+
+	for _, h := range opts.hosts {
+		args = append(args, "--host", h)
+	}
+
+	for _, p := range opts.paths {
+		args = append(args, "--path", p)
+	}
+
+	for _, t := range opts.tags {
+		args = append(args, "--tag", t)
+	}
+
+	args = append(args, opts.ids...)
+
+	return args
+}
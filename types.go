@@ -28,79 +28,119 @@ OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
 
 package restic
 
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
 type BackupSummary struct {
-	MessageType         string  `json:"message_type"`
-	FilesNew            int     `json:"files_new"`
-	FilesChanged        int     `json:"files_changed"`
-	FilesUnmodified     int     `json:"files_unmodified"`
-	DirsNew             int     `json:"dirs_new"`
-	DirsChanged         int     `json:"dirs_changed"`
-	DirsUnmodified      int     `json:"dirs_unmodified"`
-	DataBlobs           int     `json:"data_blobs"`
-	TreeBlobs           int     `json:"tree_blobs"`
-	DataAdded           int     `json:"data_added"`
-	TotalFilesProcessed int     `json:"total_files_processed"`
-	TotalBytesProcessed int     `json:"total_bytes_processed"`
-	TotalDuration       float64 `json:"total_duration"`
-	SnapshotID          string  `json:"snapshot_id"`
+	MessageType         string    `json:"message_type"`
+	FilesNew            uint64    `json:"files_new"`
+	FilesChanged        uint64    `json:"files_changed"`
+	FilesUnmodified     uint64    `json:"files_unmodified"`
+	DirsNew             uint64    `json:"dirs_new"`
+	DirsChanged         uint64    `json:"dirs_changed"`
+	DirsUnmodified      uint64    `json:"dirs_unmodified"`
+	DataBlobs           uint64    `json:"data_blobs"`
+	TreeBlobs           uint64    `json:"tree_blobs"`
+	DataAdded           uint64    `json:"data_added"`
+	DataAddedPacked     uint64    `json:"data_added_packed"`
+	TotalFilesProcessed uint64    `json:"total_files_processed"`
+	TotalBytesProcessed uint64    `json:"total_bytes_processed"`
+	TotalDuration       float64   `json:"total_duration"`
+	BackupStart         time.Time `json:"backup_start"`
+	BackupEnd           time.Time `json:"backup_end"`
+	SnapshotID          string    `json:"snapshot_id"`
+	DryRun              bool      `json:"dry_run"`
+}
+
+// Duration returns TotalDuration as time.Duration, rounded to nanoseconds
+func (s BackupSummary) Duration() time.Duration {
+	return time.Duration(math.Round(s.TotalDuration * float64(time.Second)))
+}
+
+// DataAddedHuman returns DataAdded formatted like "1.234 GiB"
+func (s BackupSummary) DataAddedHuman() string {
+	return formatBytes(s.DataAdded)
+}
+
+// DataAddedPackedHuman returns DataAddedPacked formatted like "1.234 GiB"
+func (s BackupSummary) DataAddedPackedHuman() string {
+	return formatBytes(s.DataAddedPacked)
+}
+
+// TotalBytesProcessedHuman returns TotalBytesProcessed formatted like "1.234 GiB"
+func (s BackupSummary) TotalBytesProcessedHuman() string {
+	return formatBytes(s.TotalBytesProcessed)
+}
+
+// BackupEstimate is the estimated outcome of a backup
+type BackupEstimate struct {
+	NewFiles     uint64
+	ChangedFiles uint64
+	// NewBytes is the amount of data which would be added to the repository
+	NewBytes   uint64
+	TotalFiles uint64
+	// TotalBytes is the amount of data to process
+	TotalBytes uint64
 }
 
 type RestoreSummary struct {
 	MessageType   string `json:"message_type"`
 	TotalFiles    int    `json:"total_files"`
 	FilesRestored int    `json:"files_restored"`
-	TotalBytes    int    `json:"total_bytes"`
-	BytesRestored int    `json:"bytes_restored"`
+	FilesSkipped  int    `json:"files_skipped"`
+	// FilesErrored is the number of files which could not be restored
+	FilesErrored  int `json:"files_errored"`
+	TotalBytes    int `json:"total_bytes"`
+	BytesRestored int `json:"bytes_restored"`
+	BytesSkipped  int `json:"bytes_skipped"`
+	// FilesDeleted is the number of files deleted from the target, see restore.WithDelete
+	FilesDeleted int `json:"files_deleted"`
+	// VerifyErrors is the number of restored files which failed the verification,
+	// see restore.WithVerify. restic doesn't report it in the summary, it's counted from the errors.
+	VerifyErrors int `json:"-"`
+}
+
+// SnapshotGroup holds the snapshots sharing the fields the snapshots are grouped by
+type SnapshotGroup struct {
+	Key       GroupKey   `json:"group_key"`
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+// GroupKey holds the values of the fields a group of snapshots shares,
+// fields which are not grouped by are empty
+type GroupKey struct {
+	Hostname string   `json:"hostname"`
+	Paths    []string `json:"paths"`
+	Tags     []string `json:"tags"`
 }
 
 type ForgetSummary struct {
-	Tags  []string `json:"tags"`
-	Host  string   `json:"host"`
-	Paths []string `json:"paths"`
-	Keep  []struct {
-		Time           string   `json:"time"`
-		Parent         string   `json:"parent"`
-		Tree           string   `json:"tree"`
-		Paths          []string `json:"paths"`
-		Hostname       string   `json:"hostname"`
-		Username       string   `json:"username"`
-		UID            int      `json:"uid"`
-		GID            int      `json:"gid"`
-		Tags           []string `json:"tags"`
-		ProgramVersion string   `json:"program_version"`
-		ID             string   `json:"id"`
-		ShortID        string   `json:"short_id"`
-	} `json:"keep"`
-	Remove []struct {
-		Time           string   `json:"time"`
-		Parent         string   `json:"parent"`
-		Tree           string   `json:"tree"`
-		Paths          []string `json:"paths"`
-		Hostname       string   `json:"hostname"`
-		Username       string   `json:"username"`
-		UID            int      `json:"uid"`
-		GID            int      `json:"gid"`
-		Tags           []string `json:"tags"`
-		ProgramVersion string   `json:"program_version"`
-		ID             string   `json:"id"`
-		ShortID        string   `json:"short_id"`
-	} `json:"remove"`
+	Tags    []string   `json:"tags"`
+	Host    string     `json:"host"`
+	Paths   []string   `json:"paths"`
+	Keep    []Snapshot `json:"keep"`
+	Remove  []Snapshot `json:"remove"`
 	Reasons []struct {
-		Snapshot struct {
-			Time           string   `json:"time"`
-			Parent         string   `json:"parent"`
-			Tree           string   `json:"tree"`
-			Paths          []string `json:"paths"`
-			Hostname       string   `json:"hostname"`
-			Username       string   `json:"username"`
-			UID            int      `json:"uid"`
-			GID            int      `json:"gid"`
-			Tags           []string `json:"tags"`
-			ProgramVersion string   `json:"program_version"`
-		} `json:"snapshot"`
+		Snapshot Snapshot `json:"snapshot"`
 		Matches  []string `json:"matches"`
 		Counters struct {
 			Last int `json:"last"`
 		} `json:"counters"`
 	} `json:"reasons"`
 }
+
+// formatBytes formats n with binary units like restic, e.g. "1.234 GiB"
+func formatBytes(n uint64) string {
+	units := []string{"TiB", "GiB", "MiB", "KiB"}
+	for i, unit := range units {
+		div := uint64(1) << (10 * (len(units) - i))
+		if n >= div {
+			return fmt.Sprintf("%.3f %s", float64(n)/float64(div), unit)
+		}
+	}
+
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,48 @@
+package restic
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBackupSummary_Duration(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want time.Duration
+	}{
+		{
+			name: "zero",
+			json: `{"total_duration":0}`,
+			want: 0,
+		},
+		{
+			name: "whole seconds",
+			json: `{"total_duration":42}`,
+			want: 42 * time.Second,
+		},
+		{
+			name: "fractional seconds",
+			json: `{"total_duration":12.345678}`,
+			want: 12*time.Second + 345678*time.Microsecond,
+		},
+		{
+			name: "fraction not exact as float",
+			json: `{"total_duration":1.005}`,
+			want: time.Second + 5*time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s BackupSummary
+			if err := json.Unmarshal([]byte(tt.json), &s); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Duration(); got != tt.want {
+				t.Errorf("Duration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
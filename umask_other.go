@@ -0,0 +1,13 @@
+//go:build !unix
+
+package restic
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startWithUmask starts cmd, the umask is not supported on this platform
+func startWithUmask(cmd *exec.Cmd, mask os.FileMode) error {
+	return cmd.Start()
+}
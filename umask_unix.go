@@ -0,0 +1,25 @@
+//go:build unix
+
+package restic
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// startWithUmask starts cmd with the umask mask. The umask is set by a shell in the
+// child process, which then executes the command, so the umask of the current
+// process is never changed.
+func startWithUmask(cmd *exec.Cmd, mask os.FileMode) error {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return fmt.Errorf("the umask requires sh: %w", err)
+	}
+
+	script := fmt.Sprintf(`umask %04o && exec "$@"`, mask.Perm())
+	cmd.Args = append([]string{"sh", "-c", script, "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = sh
+
+	return cmd.Start()
+}
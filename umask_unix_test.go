@@ -0,0 +1,37 @@
+//go:build unix
+
+package restic
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestStartWithUmask(t *testing.T) {
+	// read the umask of the current process without changing it for long
+	old := syscall.Umask(0022)
+	syscall.Umask(old)
+
+	out := new(bytes.Buffer)
+	cmd := exec.Command("sh", "-c", `umask; echo "$@"`, "sh", "a b", "c")
+	cmd.Stdout = out
+
+	if err := startWithUmask(cmd, 0077); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[0] != "0077" || lines[1] != "a b c" {
+		t.Errorf("output = %q, want the umask 0077 and the args", out.String())
+	}
+
+	if current := syscall.Umask(old); current != old {
+		t.Errorf("umask of the process changed from %04o to %04o", old, current)
+	}
+}
@@ -0,0 +1,34 @@
+package unlock
+
+type OptionFunc func(opts *options)
+
+type options struct {
+	removeAll bool
+}
+
+func Args(opts ...OptionFunc) []string {
+	var options options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options.args()
+}
+
+// WithRemoveAll removes all locks, even locks of processes which may still be running.
+// By default only stale locks are removed.
+func WithRemoveAll() OptionFunc {
+	return func(opts *options) {
+		opts.removeAll = true
+	}
+}
+
+func (opts options) args() []string {
+	args := make([]string, 0)
+
+	if opts.removeAll {
+		args = append(args, "--remove-all")
+	}
+
+	return args
+}